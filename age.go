@@ -0,0 +1,57 @@
+package rotate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseAge parses an age such as "7d", "4w" or "1w3d12h", for
+// retention settings read from configuration files.  On top of the
+// units of time.ParseDuration it knows d, a day of 24 hours, and w, a
+// week of 7 days.
+func ParseAge(s string) (time.Duration, error) {
+	t := strings.TrimSpace(s)
+	if t == "" {
+		return 0, fmt.Errorf("rotate: bad age %q", s)
+	}
+	var total time.Duration
+	for t != "" {
+		i := strings.IndexFunc(t, func(c rune) bool {
+			return (c < '0' || c > '9') && c != '.'
+		})
+		if i <= 0 {
+			return 0, fmt.Errorf("rotate: bad age %q", s)
+		}
+		j := strings.IndexFunc(t[i:], func(c rune) bool {
+			return (c >= '0' && c <= '9') || c == '.'
+		})
+		if j < 0 {
+			j = len(t) - i
+		}
+		num, unit := t[:i], t[i:i+j]
+		t = t[i+j:]
+
+		var d time.Duration
+		switch unit {
+		case "d", "w":
+			f, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return 0, fmt.Errorf("rotate: bad age %q", s)
+			}
+			day := 24 * time.Hour
+			if unit == "w" {
+				day *= 7
+			}
+			d = time.Duration(f * float64(day))
+		default:
+			var err error
+			if d, err = time.ParseDuration(num + unit); err != nil {
+				return 0, fmt.Errorf("rotate: bad age %q", s)
+			}
+		}
+		total += d
+	}
+	return total, nil
+}
@@ -0,0 +1,73 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	day := 24 * time.Hour
+	for _, c := range []struct {
+		s    string
+		want time.Duration
+	}{
+		{"7d", 7 * day},
+		{"4w", 28 * day},
+		{"90d", 90 * day},
+		{"1w3d12h", 10*day + 12*time.Hour},
+		{"1.5d", 36 * time.Hour},
+		{"90m", 90 * time.Minute},
+	} {
+		d, err := ParseAge(c.s)
+		if err != nil {
+			t.Errorf("%q: %v", c.s, err)
+			continue
+		}
+		if d != c.want {
+			t.Errorf("%q: %v, expected %v", c.s, d, c.want)
+		}
+	}
+	for _, s := range []string{"", "d", "7", "7y", "-3d"} {
+		if _, err := ParseAge(s); err == nil {
+			t.Errorf("%q: no error", s)
+		}
+	}
+}
+
+func TestMaxAge(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	old := time.Now().Add(-10 * 24 * time.Hour)
+	for _, name := range []string{"mt_1", "mt_2", "mt_3"} {
+		p := path.Join(root, name)
+		if err := ioutil.WriteFile(p, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if name != "mt_3" {
+			if err := os.Chtimes(p, old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	o := DefaultOptions()
+	o.MaxAge, _ = ParseAge("7d")
+	x, err := New(root, "mt", WithOptions(o))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 1 || archives[0].Name != "mt_3" {
+		t.Errorf("archives left: %v", archives)
+	}
+}
@@ -0,0 +1,79 @@
+package rotate
+
+import (
+	"io"
+	"os"
+)
+
+// SetArchiveDir sets the directory that rotated files are moved to.
+// By default they stay in root.  It is created if necessary and may
+// be on a different filesystem than root, so the current file can
+// stay on fast local storage while archives go to bulk storage;
+// archives are then copied rather than renamed.
+func (r *Writer) SetArchiveDir(dir string) {
+	r.Lock()
+	defer r.Unlock()
+	lay := *r.layout()
+	lay.archDir = dir
+	r.lay.Store(&lay)
+}
+
+// archiveRoot returns the directory that archives are kept in.
+func (r *Writer) archiveRoot() string {
+	if dir := r.layout().archDir; dir != "" {
+		return dir
+	}
+	return r.layout().root
+}
+
+// moveFile renames src to dst, falling back to a copy when they are
+// on different filesystems.
+func moveFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if isCrossDevice(err) {
+		return copyFile(src, dst)
+	}
+	return err
+}
+
+// copyFile copies src to dst and removes src.  The copy is written
+// to dst.tmp and renamed when complete, so dst never holds partial
+// data.  Extended attributes, such as the SELinux context, are copied
+// as far as possible.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	fi, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp := dst + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fi.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	copyXattrs(src, tmp)
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(src)
+}
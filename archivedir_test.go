@@ -0,0 +1,71 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestArchiveDir(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	arch := path.Join(root, "archive")
+
+	x, err := New(path.Join(root, "live"), "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(5)
+	x.SetKeep(1)
+	x.SetArchiveDir(arch)
+	for i := 0; i < 2; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, err := ioutil.ReadDir(path.Join(root, "live"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0].Name() != fileDefault {
+		t.Errorf("live directory: %d files, expected only %s", len(names), fileDefault)
+	}
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 1 || archives[0].Path != path.Join(arch, "mt_2") {
+		t.Errorf("archives: %+v", archives)
+	}
+}
+
+func TestCopyFile(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	src, dst := path.Join(root, "src"), path.Join(root, "dst")
+	if err := ioutil.WriteFile(src, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := copyFile(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("src still exists: %v", err)
+	}
+	data, err := ioutil.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello\n" {
+		t.Errorf("dst: %q, expected %q", data, "hello\n")
+	}
+}
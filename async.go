@@ -0,0 +1,304 @@
+package rotate
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// queueLenDefault is the number of writes an asynchronous Writer
+// holds when AsyncOptions.QueueLen is not set.
+const queueLenDefault = 1024
+
+// AsyncOptions configures asynchronous writing.  See WithAsync.
+type AsyncOptions struct {
+	// QueueLen is the number of writes that can wait to be
+	// written.  The default is 1024.
+	QueueLen int
+
+	// MaxPendingBytes, if positive, also bounds the number of
+	// bytes waiting to be written, so a burst of large writes can
+	// not use up memory before the disk catches up.  A single write
+	// larger than the bound is taken when the queue is empty.
+	MaxPendingBytes int
+
+	// DropWhenFull makes a Write fail with ErrQueueFull when the
+	// queue is full, rather than wait for room.  Use it when
+	// losing log data is better than stalling the caller.
+	DropWhenFull bool
+}
+
+// AsyncStats describes the queue of an asynchronous Writer, for
+// capacity planning.  Counters start at zero when the Writer is
+// created.
+type AsyncStats struct {
+	// QueueLen is the capacity of the queue, Depth the number of
+	// writes waiting in it now, and HighWater the most that have
+	// ever been waiting.
+	QueueLen  int
+	Depth     int
+	HighWater int
+
+	// PendingBytes is the number of bytes waiting now, and
+	// MaxPendingBytes the bound on it, or zero if there is none.
+	PendingBytes    int
+	MaxPendingBytes int
+
+	// Enqueued is the number of writes accepted, and Dropped and
+	// DroppedBytes count the writes rejected with ErrQueueFull.
+	Enqueued     uint64
+	Dropped      uint64
+	DroppedBytes uint64
+
+	// EnqueueWait is the total time writes have spent waiting for
+	// room in the queue, and MaxEnqueueWait the longest any one of
+	// them waited.
+	EnqueueWait    time.Duration
+	MaxEnqueueWait time.Duration
+}
+
+// WithAsync makes Write copy the data into a queue and return,
+// leaving a background goroutine to write it to the file.  Callers
+// are not slowed down by the disk or by rotations, but errors are no
+// longer returned by Write: they are reported to the error handler
+// and by Health.  WriteVector and WriteBatch queue their records
+// too.  Close writes everything still queued.
+func WithAsync(o AsyncOptions) Option {
+	return func(r *Writer) {
+		r.asyncOpts = &o
+	}
+}
+
+// WithMaxPending bounds the queue of an asynchronous Writer to
+// entries writes and bytes bytes, overriding the QueueLen and
+// MaxPendingBytes given to WithAsync.  A bound of zero is left as it
+// was.  It must be used together with WithAsync.
+func WithMaxPending(entries, bytes int) Option {
+	return func(r *Writer) {
+		if entries > 0 {
+			r.maxPendingEntries = entries
+		}
+		if bytes > 0 {
+			r.maxPendingBytes = bytes
+		}
+	}
+}
+
+// setupAsync creates the queue from the options given to New.
+func (r *Writer) setupAsync() error {
+	if r.asyncOpts == nil {
+		if r.maxPendingEntries > 0 || r.maxPendingBytes > 0 {
+			return errors.New("rotate: WithMaxPending requires WithAsync")
+		}
+		return nil
+	}
+	o := *r.asyncOpts
+	if r.maxPendingEntries > 0 {
+		o.QueueLen = r.maxPendingEntries
+	}
+	if r.maxPendingBytes > 0 {
+		o.MaxPendingBytes = r.maxPendingBytes
+	}
+	if o.QueueLen <= 0 {
+		o.QueueLen = queueLenDefault
+	}
+	q := &asyncQueue{
+		ch:       make(chan queued, o.QueueLen),
+		drop:     o.DropWhenFull,
+		done:     make(chan struct{}),
+		maxBytes: o.MaxPendingBytes,
+	}
+	q.room = sync.NewCond(&q.bytesMu)
+	r.async = q
+	return nil
+}
+
+// AsyncStats returns statistics about the queue of an asynchronous
+// Writer.  It returns the zero AsyncStats if the Writer is not
+// asynchronous.
+func (r *Writer) AsyncStats() AsyncStats {
+	q := r.async
+	if q == nil {
+		return AsyncStats{}
+	}
+	q.bytesMu.Lock()
+	pending := q.bytes
+	q.bytesMu.Unlock()
+	return AsyncStats{
+		QueueLen:        cap(q.ch),
+		Depth:           len(q.ch),
+		HighWater:       int(atomic.LoadInt64(&q.highWater)),
+		PendingBytes:    pending,
+		MaxPendingBytes: q.maxBytes,
+		Enqueued:        atomic.LoadUint64(&q.enqueued),
+		Dropped:         atomic.LoadUint64(&q.dropped),
+		DroppedBytes:    atomic.LoadUint64(&q.droppedBytes),
+		EnqueueWait:     time.Duration(atomic.LoadInt64(&q.wait)),
+		MaxEnqueueWait:  time.Duration(atomic.LoadInt64(&q.maxWait)),
+	}
+}
+
+// asyncQueue holds the writes of an asynchronous Writer.  It is set
+// by New and never changed, so it is used without the Writer's lock.
+type asyncQueue struct {
+	ch   chan queued
+	drop bool
+	done chan struct{}
+
+	// mu is held for reading while a write is queued and for
+	// writing while the queue is closed, so nothing is sent on a
+	// closed channel.
+	mu     sync.RWMutex
+	closed bool
+
+	// bytes is the number of bytes queued.  room is signalled
+	// when it goes down.
+	bytesMu  sync.Mutex
+	room     *sync.Cond
+	bytes    int
+	maxBytes int
+
+	highWater    int64
+	enqueued     uint64
+	dropped      uint64
+	droppedBytes uint64
+	wait         int64
+	maxWait      int64
+}
+
+// queued is a write waiting in the queue.  record is set if it was
+// made with WriteRecord.
+type queued struct {
+	p      []byte
+	record bool
+}
+
+// enqueue queues a copy of p.
+func (q *asyncQueue) enqueue(p []byte, record bool) (int, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return 0, ErrClosed
+	}
+	start := time.Now()
+	waited := false
+	q.bytesMu.Lock()
+	for q.maxBytes > 0 && q.bytes > 0 && q.bytes+len(p) > q.maxBytes {
+		if q.drop {
+			q.bytesMu.Unlock()
+			return 0, q.dropWrite(p)
+		}
+		waited = true
+		q.room.Wait()
+	}
+	q.bytes += len(p)
+	q.bytesMu.Unlock()
+
+	b := queued{p: append([]byte(nil), p...), record: record}
+	select {
+	case q.ch <- b:
+	default:
+		if q.drop {
+			q.release(len(p))
+			return 0, q.dropWrite(p)
+		}
+		waited = true
+		q.ch <- b
+	}
+	if waited {
+		wait := int64(time.Since(start))
+		atomic.AddInt64(&q.wait, wait)
+		storeMax(&q.maxWait, wait)
+	}
+	atomic.AddUint64(&q.enqueued, 1)
+	storeMax(&q.highWater, int64(len(q.ch)))
+	return len(p), nil
+}
+
+// dropWrite counts p as dropped and returns ErrQueueFull.
+func (q *asyncQueue) dropWrite(p []byte) error {
+	atomic.AddUint64(&q.dropped, 1)
+	atomic.AddUint64(&q.droppedBytes, uint64(len(p)))
+	return ErrQueueFull
+}
+
+// release makes room for n more bytes.
+func (q *asyncQueue) release(n int) {
+	q.bytesMu.Lock()
+	q.bytes -= n
+	q.bytesMu.Unlock()
+	q.room.Broadcast()
+}
+
+// close stops the queue from taking writes and waits until the ones
+// already queued have been written.
+func (q *asyncQueue) close() error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return ErrClosed
+	}
+	q.closed = true
+	close(q.ch)
+	q.mu.Unlock()
+	<-q.done
+	return nil
+}
+
+// storeMax raises *addr to v if v is greater.
+func storeMax(addr *int64, v int64) {
+	for {
+		old := atomic.LoadInt64(addr)
+		if v <= old || atomic.CompareAndSwapInt64(addr, old, v) {
+			return
+		}
+	}
+}
+
+// drain writes the queued writes until the queue is closed.  Writes
+// that are waiting together are written under one acquisition of
+// the lock.
+func (r *Writer) drain() {
+	q := r.async
+	defer close(q.done)
+	for e := range q.ch {
+		r.Lock()
+		onError := r.onError
+		err := r.drainWrite(e)
+	more:
+		for err == nil {
+			select {
+			case e, ok := <-q.ch:
+				if !ok {
+					break more
+				}
+				err = r.drainWrite(e)
+			default:
+				break more
+			}
+		}
+		r.Unlock()
+		if err != nil {
+			r.recordError(err)
+			if onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// drainWrite writes e, which was queued.  The lock must be held.
+func (r *Writer) drainWrite(e queued) error {
+	defer r.async.release(len(e.p))
+	if err := r.ready(); err != nil {
+		return err
+	}
+	var err error
+	if e.record {
+		_, err = r.writeRecord(e.p)
+	} else {
+		_, err = r.writeData(e.p)
+	}
+	return err
+}
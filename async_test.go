@@ -0,0 +1,111 @@
+package rotate
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestAsync(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt", WithAsync(AsyncOptions{QueueLen: 4}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected strings.Builder
+	for i := 0; i < 100; i++ {
+		line := fmt.Sprintf("line %d\n", i)
+		expected.WriteString(line)
+		if _, err := x.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(path.Join(root, "default.log")); string(b) != expected.String() {
+		t.Errorf("current: %d bytes, expected %d in order", len(b), expected.Len())
+	}
+	st := x.AsyncStats()
+	if st.QueueLen != 4 || st.Enqueued != 100 || st.Depth != 0 || st.HighWater < 1 || st.HighWater > 4 {
+		t.Errorf("stats: %+v", st)
+	}
+	if _, err := x.Write([]byte("late\n")); !errors.Is(err, ErrClosed) {
+		t.Errorf("Write after Close: %v, expected ErrClosed", err)
+	}
+}
+
+func TestAsyncDrop(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt", WithAsync(AsyncOptions{QueueLen: 1, DropWhenFull: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// stall the background writer
+	x.Lock()
+	var dropped int
+	for i := 0; i < 3; i++ {
+		if _, err := x.Write([]byte("hello\n")); errors.Is(err, ErrQueueFull) {
+			dropped++
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	x.Unlock()
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+	st := x.AsyncStats()
+	if dropped == 0 || st.Dropped != uint64(dropped) || st.DroppedBytes != uint64(6*dropped) || st.Enqueued+st.Dropped != 3 {
+		t.Errorf("dropped %d, stats: %+v", dropped, st)
+	}
+}
+
+func TestMaxPending(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	if _, err := New(root, "mt", WithMaxPending(10, 100)); err == nil {
+		t.Error("WithMaxPending without WithAsync succeeded")
+	}
+
+	x, err := New(root, "mt", WithAsync(AsyncOptions{DropWhenFull: true}), WithMaxPending(0, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// stall the background writer
+	x.Lock()
+	if _, err := x.Write([]byte("0123456789abcdef")); err != nil {
+		t.Errorf("oversized write to an empty queue: %v", err)
+	}
+	if _, err := x.Write([]byte("x")); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("write past the byte bound: %v, expected ErrQueueFull", err)
+	}
+	if st := x.AsyncStats(); st.QueueLen != queueLenDefault || st.MaxPendingBytes != 10 || st.PendingBytes != 16 {
+		t.Errorf("stats: %+v", st)
+	}
+	x.Unlock()
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if st := x.AsyncStats(); st.PendingBytes != 0 || st.Dropped != 1 {
+		t.Errorf("stats after Close: %+v", st)
+	}
+}
@@ -0,0 +1,61 @@
+package rotate
+
+// WriteBatch writes records in order under a single acquisition of
+// the lock, so collectors that accumulate events pay for locking
+// once per batch rather than once per event.  Each record is treated
+// as a separate Write: the file is rotated between records, never in
+// the middle of one.  Records that fit in the current file are
+// written together with WriteVector's single system call.
+//
+// WriteBatch returns the number of records written in full, which is
+// less than len(records) only if err is not nil.
+func (r *Writer) WriteBatch(records [][]byte) (int, error) {
+	if r.async != nil {
+		for i, p := range records {
+			if _, err := r.async.enqueue(p, false); err != nil {
+				return i, err
+			}
+		}
+		return len(records), nil
+	}
+	r.Lock()
+	defer r.Unlock()
+	if err := r.ready(); err != nil {
+		return 0, err
+	}
+	if r.intercepting() {
+		for i, p := range records {
+			if _, err := r.writeIntercepted(p); err != nil {
+				return i, err
+			}
+		}
+		return len(records), nil
+	}
+
+	done := 0
+	for done < len(records) {
+		// gather the records up to and including the one that
+		// fills the current file
+		end, size := done, r.size
+		for end < len(records) && size < r.max && r.trigger == nil {
+			size += len(records[end])
+			end++
+		}
+		if end == done {
+			end++
+		}
+		n, err := r.writeVector(records[done:end])
+		if err != nil {
+			for _, p := range records[done:end] {
+				if n < int64(len(p)) {
+					break
+				}
+				n -= int64(len(p))
+				done++
+			}
+			return done, err
+		}
+		done = end
+	}
+	return done, nil
+}
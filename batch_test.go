@@ -0,0 +1,50 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestWriteBatch(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(8)
+	records := [][]byte{
+		[]byte("one\n"), []byte("two\n"), []byte("three\n"),
+		[]byte("four\n"), []byte("five\n"),
+	}
+	n, err := x.WriteBatch(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(records) {
+		t.Errorf("wrote %d records, expected %d", n, len(records))
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"mt_1":        "one\ntwo\n",
+		"mt_2":        "three\nfour\n",
+		"default.log": "five\n",
+	}
+	for name, s := range expected {
+		if b, _ := ioutil.ReadFile(path.Join(root, name)); string(b) != s {
+			t.Errorf("%s: %q, expected %q", name, b, s)
+		}
+	}
+	if _, err := x.WriteBatch(records); err != ErrClosed {
+		t.Errorf("WriteBatch after Close: %v, expected ErrClosed", err)
+	}
+}
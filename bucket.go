@@ -0,0 +1,106 @@
+package rotate
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+const bucketPatternDefault = "{prefix}_{date}.log"
+
+// WithTimeBuckets makes the Writer append directly to files named
+// for the period of length period they cover, such as
+// prefix_2024-05-01.log for daily periods, instead of writing to a
+// current file that is renamed at rotation.  pattern is a name
+// pattern, as for SetNamePattern, expanded with the start of each
+// period; empty means "{prefix}_{date}.log".  Periods start at
+// multiples of period in local time, so daily periods start at
+// midnight.
+//
+// A new file is started at the first write after the clock crosses
+// into a new period.  As nothing is ever renamed, programs tailing
+// the files never race with a rotation.  The files of past periods
+// are the archives, subject to retention and passed to the sink;
+// maximum sizes and Triggers do not apply, and the files stay in
+// root.  Settings given later by WithOptions, WithConfig or Update
+// keep pattern, unless they name archives with a pattern other than
+// the default, which is an error.
+func WithTimeBuckets(period time.Duration, pattern string) Option {
+	return func(r *Writer) {
+		if pattern == "" {
+			pattern = bucketPatternDefault
+		}
+		if period <= 0 {
+			r.optErr = fmt.Errorf("rotate: time bucket period must be positive")
+			return
+		}
+		nameRE, err := r.compileNamePattern(pattern)
+		if err != nil {
+			r.optErr = err
+			return
+		}
+		lay := *r.layout()
+		lay.namePattern = pattern
+		lay.nameRE = nameRE
+		r.lay.Store(&lay)
+		r.bucket = period
+	}
+}
+
+// bucketPattern returns the name pattern to use for pattern, given
+// in Options.  A Writer with time buckets keeps the pattern given to
+// WithTimeBuckets unless pattern is another one than the default.
+func (r *Writer) bucketPattern(pattern string) (string, error) {
+	cur := r.layout().namePattern
+	if r.bucket <= 0 || pattern == cur {
+		return pattern, nil
+	}
+	if pattern == "" || pattern == namePatternDefault {
+		return cur, nil
+	}
+	return "", fmt.Errorf("rotate: name pattern %q conflicts with time bucket pattern %q", pattern, cur)
+}
+
+// bucketStart returns the start of the period of length d that t is
+// in, in t's location.
+func bucketStart(t time.Time, d time.Duration) time.Time {
+	_, off := t.Zone()
+	shift := time.Duration(off) * time.Second
+	return t.Add(shift).Truncate(d).Add(-shift)
+}
+
+// nameBucket names the current file for the period that t is in.
+// The lock must be held.
+func (r *Writer) nameBucket(t time.Time) {
+	r.bucketStart = bucketStart(t, r.bucket)
+	r.fileName = r.expandName(r.counter, r.bucketStart)
+}
+
+// rollBucket moves on to the file of the current period if the clock
+// has left the period of the current file.  The file of the period
+// that ended becomes an archive.  The lock must be held.
+func (r *Writer) rollBucket() error {
+	if r.bucket <= 0 || r.passthrough || r.discard || time.Now().Before(r.bucketStart.Add(r.bucket)) {
+		return nil
+	}
+	prev, start, live, tags := r.currentName(), r.opened, r.live, r.tags
+	if err := r.closeCurrent(); err != nil {
+		r.recordError(fmt.Errorf("rotate: close current file: %w", err))
+	}
+	r.tags = nil
+	if err := r.openCurrent(); err != nil {
+		r.recordError(err)
+		return err
+	}
+	now := time.Now()
+	r.lastRotation = now
+	atomic.AddUint64(&r.stats.rotations, 1)
+	r.logf("moved on from %s to %s", prev, r.currentName())
+
+	// the file of the period that ended is archived like a
+	// rotated one, though it keeps its name
+	if err := r.finishRotation(prev, 0, start, now, live, ReasonBucket, tags); err != nil {
+		r.recordError(err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestTimeBuckets(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	if _, err := New(root, "mt", WithTimeBuckets(time.Hour, "{prefix}.log")); err == nil {
+		t.Error("a pattern that does not change with time succeeded")
+	}
+
+	x, err := New(root, "mt", WithTimeBuckets(time.Second, "{prefix}_{date}_{time}.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(1)
+	first := x.currentPath()
+	if _, err := x.Write([]byte("one\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.Write([]byte("two\n")); err != nil {
+		t.Fatal(err)
+	}
+	if x.currentPath() != first {
+		t.Error("moved on within the period")
+	}
+	if archives, _ := x.Archives(); len(archives) != 0 {
+		t.Errorf("archives during the first period: %v", archives)
+	}
+
+	time.Sleep(time.Until(bucketStart(time.Now(), time.Second).Add(time.Second)))
+	if _, err := x.Write([]byte("three\n")); err != nil {
+		t.Fatal(err)
+	}
+	x.Close()
+	if x.currentPath() == first {
+		t.Error("did not move on to the next period")
+	}
+	if b, _ := ioutil.ReadFile(first); string(b) != "one\ntwo\n" {
+		t.Errorf("first period: %q", b)
+	}
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 1 || archives[0].Path != first {
+		t.Errorf("archives: %v", archives)
+	}
+	if _, err := os.Stat(path.Join(root, "default.log")); !os.IsNotExist(err) {
+		t.Errorf("default.log: %v, expected none", err)
+	}
+}
+
+func TestBucketStart(t *testing.T) {
+	loc := time.FixedZone("X", 2*3600)
+	got := bucketStart(time.Date(2024, 5, 1, 1, 30, 0, 0, loc), 24*time.Hour)
+	if want := time.Date(2024, 5, 1, 0, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("daily bucket: %v, expected %v", got, want)
+	}
+}
+
+func TestTimeBucketArchives(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	pattern := "{prefix}_{date}_{time}.log"
+	o := DefaultOptions()
+	o.NamePattern = "{prefix}.{n}"
+	if _, err := New(root, "mt", WithTimeBuckets(time.Second, pattern), WithOptions(o)); err == nil {
+		t.Error("conflicting name patterns accepted")
+	}
+	x, err := New(root, "mt", WithTimeBuckets(time.Second, pattern), WithOptions(DefaultOptions()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	if p := x.Options().NamePattern; p != pattern {
+		t.Errorf("name pattern: %s", p)
+	}
+	x.SetSidecar(true)
+	x.TagCurrent("incident", "1234")
+	events := x.Events()
+	if _, err := x.Write([]byte("one\n")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Until(bucketStart(time.Now(), time.Second).Add(time.Second)))
+	if _, err := x.Write([]byte("two\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 1 {
+		t.Fatalf("archives: %+v", archives)
+	}
+	meta := archives[0].Meta
+	if meta == nil || meta.Lines != 1 || meta.Reason != ReasonBucket || meta.Tags["incident"] != "1234" {
+		t.Errorf("metadata: %+v", meta)
+	}
+	select {
+	case e := <-events:
+		if e.Kind != EventRotated || e.Reason != ReasonBucket || e.Archive.Name != archives[0].Name {
+			t.Errorf("event: %+v", e)
+		}
+	default:
+		t.Error("no event")
+	}
+}
@@ -0,0 +1,57 @@
+package rotate
+
+import "fmt"
+
+// WithBuffer makes the Writer hold up to size bytes in memory and
+// write them to the current file in fewer, larger writes.  Buffered
+// data is written when the buffer is full, on Flush, when the file
+// is rotated or closed, and after every line with
+// SetFlushOnNewline.  It is lost if the program crashes, and readers
+// such as Tail do not see it until then.
+func WithBuffer(size int) Option {
+	return func(r *Writer) {
+		r.bufSize = size
+	}
+}
+
+// SetFlushOnNewline sets whether the buffer is flushed after every
+// write that ends with a newline, so complete lines are promptly
+// visible to tail while partial ones are still collected into one
+// write.  It has no effect without WithBuffer.
+func (r *Writer) SetFlushOnNewline(on bool) {
+	r.Lock()
+	r.flushNL = on
+	r.Unlock()
+}
+
+// Flush writes the buffered data to the current file.  With
+// WithAsync, only the writes already taken off the queue are
+// flushed.
+func (r *Writer) Flush() error {
+	r.Lock()
+	defer r.Unlock()
+	if r.current == nil && !r.unopened {
+		return ErrClosed
+	}
+	return r.flush()
+}
+
+// flush writes the buffer, if any.  The lock must be held.
+func (r *Writer) flush() error {
+	if r.buf == nil {
+		return nil
+	}
+	if err := r.buf.Flush(); err != nil {
+		return fmt.Errorf("rotate: flush: %w", err)
+	}
+	return nil
+}
+
+// flushLine flushes the buffer after p was written if p completes a
+// line and SetFlushOnNewline is set.  The lock must be held.
+func (r *Writer) flushLine(p []byte) error {
+	if !r.flushNL || len(p) == 0 || p[len(p)-1] != '\n' {
+		return nil
+	}
+	return r.flush()
+}
@@ -0,0 +1,64 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestBuffer(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt", WithBuffer(4096))
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := path.Join(root, fileDefault)
+	read := func() string {
+		b, _ := ioutil.ReadFile(current)
+		return string(b)
+	}
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if s := read(); s != "" {
+		t.Errorf("file before flush: %q", s)
+	}
+	if err := x.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if s := read(); s != "hello\n" {
+		t.Errorf("file after flush: %q", s)
+	}
+
+	x.SetFlushOnNewline(true)
+	if _, err := x.Write([]byte("par")); err != nil {
+		t.Fatal(err)
+	}
+	if s := read(); s != "hello\n" {
+		t.Errorf("file after partial line: %q", s)
+	}
+	if _, err := x.WriteVector([][]byte{[]byte("tial"), []byte("\n")}); err != nil {
+		t.Fatal(err)
+	}
+	if s := read(); s != "hello\npartial\n" {
+		t.Errorf("file after newline: %q", s)
+	}
+
+	x.SetFlushOnNewline(false)
+	x.SetMax(20)
+	if _, err := x.Write([]byte("rotated\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(path.Join(root, "mt_1")); string(b) != "hello\npartial\nrotated\n" {
+		t.Errorf("archive: %q", b)
+	}
+}
@@ -0,0 +1,219 @@
+package rotate
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Entries in a bundle are stored under these directories.
+const (
+	bundleArchives = "archives/"
+	bundleCurrent  = "current/"
+)
+
+// ExportBundle writes the archives, with their metadata, and the
+// current file to w as a gzip compressed tar file.  The current file
+// is included as it is when ExportBundle reaches it.
+func (r *Writer) ExportBundle(w io.Writer) error {
+	archives, err := r.archives()
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, a := range archives {
+		if err := addToBundle(tw, a.Path, bundleArchives+a.Name); err != nil {
+			if os.IsNotExist(err) {
+				// deleted by retention since it was listed
+				continue
+			}
+			return fmt.Errorf("rotate: export %s: %w", a.Name, err)
+		}
+		err := addToBundle(tw, a.Path+metaSuffix, bundleArchives+a.Name+metaSuffix)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("rotate: export %s: %w", a.Name+metaSuffix, err)
+		}
+	}
+	cp := r.currentPath()
+	if err := addToBundle(tw, cp, bundleCurrent+path.Base(cp)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("rotate: export current file: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// addToBundle adds the file at p to tw as name.  Only as many bytes
+// as the file has when it is opened are added, since the current
+// file may still be growing.
+func addToBundle(tw *tar.Writer, p, name string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     fi.Size(),
+		Mode:     int64(fi.Mode().Perm()),
+		ModTime:  fi.ModTime(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, io.LimitReader(f, fi.Size()))
+	return err
+}
+
+// ImportBundle restores the files in a bundle written by
+// ExportBundle, possibly by a Writer with another prefix or name
+// pattern, as archives of this Writer.  Archives keep their counter
+// if their name fits this Writer's pattern and is not taken, and get
+// the next counter otherwise.  The bundle's current file becomes the
+// newest imported archive.  The counter is moved past all imported
+// archives.
+//
+// Retention is applied at the next rotation, so raise the keep limit
+// first if everything in the bundle should be kept.
+func (r *Writer) ImportBundle(rd io.Reader) error {
+	r.Lock()
+	defer r.Unlock()
+
+	gz, err := gzip.NewReader(rd)
+	if err != nil {
+		return fmt.Errorf("rotate: import bundle: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	var last string // path of the last archive imported
+	var dirs []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("rotate: import bundle: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(hdr.Name)
+		switch {
+		case strings.HasPrefix(name, bundleArchives) && strings.HasSuffix(name, metaSuffix):
+			if last == "" {
+				continue
+			}
+			if err := r.importFile(tr, hdr, last+metaSuffix); err != nil {
+				return fmt.Errorf("rotate: import %s: %w", hdr.Name, err)
+			}
+		case strings.HasPrefix(name, bundleArchives), strings.HasPrefix(name, bundleCurrent):
+			dst, err := r.importName(path.Base(name), hdr)
+			if err != nil {
+				return fmt.Errorf("rotate: import %s: %w", hdr.Name, err)
+			}
+			if err := r.importFile(tr, hdr, dst); err != nil {
+				return fmt.Errorf("rotate: import %s: %w", hdr.Name, err)
+			}
+			name := strings.TrimPrefix(dst, r.archiveRoot()+"/")
+			if err := r.chainAdded(ArchiveInfo{Name: name, Path: dst}); err != nil {
+				return err
+			}
+			last = dst
+			r.logf("imported %s as %s", hdr.Name, dst)
+			dirs = append(dirs, path.Dir(dst))
+		}
+	}
+	if r.layout().syncDirs {
+		if err := syncDirs(dirs...); err != nil {
+			return fmt.Errorf("rotate: sync directories: %w", err)
+		}
+	}
+	return r.writeManifest()
+}
+
+// importName chooses the path for an imported file that was named
+// base, and moves the counter past it.  The lock must be held.
+func (r *Writer) importName(base string, hdr *tar.Header) (string, error) {
+	var ext string
+	plain := strings.TrimSuffix(base, encExt)
+	if c := compressorFor(plain); c != nil {
+		ext = c.Ext()
+	}
+	if plain != base {
+		ext += encExt
+	}
+	dir := r.archiveRoot()
+	if r.layout().dated {
+		d, err := r.makeDatedDir(hdr.ModTime)
+		if err != nil {
+			return "", err
+		}
+		dir = path.Join(dir, d)
+	} else if err := r.mkdirAll(dir); err != nil {
+		return "", err
+	}
+
+	if counter, ok := r.parseName(base); ok {
+		dst := path.Join(dir, base)
+		if _, err := os.Stat(dst); os.IsNotExist(err) {
+			if counter >= r.counter {
+				r.counter = counter + 1
+			}
+			return dst, nil
+		}
+	}
+	for i := 0; i < 1000; i++ {
+		dst := path.Join(dir, r.expandName(r.counter, hdr.ModTime)+ext)
+		r.counter++
+		if _, err := os.Stat(dst); os.IsNotExist(err) {
+			return dst, nil
+		}
+	}
+	return "", fmt.Errorf("%w: no free archive name for %s", ErrNameCollision, base)
+}
+
+// importFile writes the contents of the current tar entry to dst.
+func (r *Writer) importFile(tr *tar.Reader, hdr *tar.Header, dst string) error {
+	tmp := dst + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, r.filePerm)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(f, tr)
+	if err == nil && n != hdr.Size {
+		err = fmt.Errorf("rotate: %s: short bundle entry", hdr.Name)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chtimes(tmp, hdr.ModTime, hdr.ModTime)
+	}
+	if err == nil {
+		// the file was created under the umask
+		err = r.fixPerm(tmp)
+	}
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
@@ -0,0 +1,127 @@
+package rotate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestBundle(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(path.Join(root, "a"), "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetSidecar(true)
+	x.SetMax(5)
+	for _, s := range []string{"one\n", "two\n", "three\n", "cur"} {
+		if _, err := x.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := x.ExportBundle(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// y names archives differently, so the bundle's are renamed to
+	// follow y's own
+	y, err := New(path.Join(root, "b"), "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := y.SetNamePattern("{prefix}.{n}.log"); err != nil {
+		t.Fatal(err)
+	}
+	y.SetMax(1)
+	if _, err := y.Write([]byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := y.ImportBundle(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	archives, err := y.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"mt.1.log", "mt.2.log", "mt.3.log", "mt.4.log"}
+	contents := []string{"new\n", "one\ntwo\n", "three\n", "cur"}
+	if len(archives) != len(expected) {
+		t.Fatalf("archives: %+v", archives)
+	}
+	for i, a := range archives {
+		data, err := ioutil.ReadFile(a.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Name != expected[i] || string(data) != contents[i] {
+			t.Errorf("archive %d: %s %q, expected %s %q", i, a.Name, data, expected[i], contents[i])
+		}
+	}
+	if archives[1].Meta == nil {
+		t.Error("metadata not imported")
+	}
+	if c := y.GetCounter(); c != 5 {
+		t.Errorf("counter: %d, expected 5", c)
+	}
+}
+
+func TestBundleEncryptedCollision(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	kr := testKeyRing()
+	var bufs []*bytes.Buffer
+	var writers []*Writer
+	for _, dir := range []string{"a", "b"} {
+		x, err := New(path.Join(root, dir), "mt", WithLiveCompression(Encrypted(kr, Gzip)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer x.Close()
+		x.SetMax(1)
+		if _, err := x.Write([]byte(dir + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		writers = append(writers, x)
+		bufs = append(bufs, new(bytes.Buffer))
+	}
+	if err := writers[0].ExportBundle(bufs[0]); err != nil {
+		t.Fatal(err)
+	}
+	// the bundle's mt_1.gz.enc is taken in b
+	if err := writers[1].ImportBundle(bufs[0]); err != nil {
+		t.Fatal(err)
+	}
+	archives, err := writers[1].Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, a := range archives {
+		names = append(names, a.Name)
+	}
+	if len(names) != 3 || names[1] != "mt_2.gz.enc" {
+		t.Fatalf("archives: %q", names)
+	}
+	rc, err := writers[1].OpenArchive(archives[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(data) != "a\n" {
+		t.Errorf("imported archive: %q, %v", data, err)
+	}
+}
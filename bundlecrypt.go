@@ -0,0 +1,70 @@
+package rotate
+
+import (
+	"fmt"
+	"io"
+)
+
+// An Encrypter encrypts a stream, such as an exported bundle, for
+// its recipients.
+type Encrypter interface {
+	// Encrypt returns a WriteCloser that writes what is written
+	// to it, encrypted, to w.  Close must be called to finish the
+	// stream; it does not close w.
+	Encrypt(w io.Writer) (io.WriteCloser, error)
+}
+
+// AgeEncrypter returns an Encrypter using the age program, which must
+// be in the PATH, for the recipients' public keys: age1... keys or
+// SSH public keys, as given to age -r.
+func AgeEncrypter(recipients ...string) Encrypter {
+	args := []string{"age", "--encrypt"}
+	for _, r := range recipients {
+		args = append(args, "--recipient", r)
+	}
+	return CommandEncrypter(args...)
+}
+
+// GPGEncrypter returns an Encrypter using the gpg program, which must
+// be in the PATH, for the recipients whose public keys are in the
+// files keyFiles, ASCII armored or not.  The keys do not need to be
+// in, or trusted by, the keyring.
+func GPGEncrypter(keyFiles ...string) Encrypter {
+	args := []string{"gpg", "--batch", "--no-tty", "--encrypt", "--output", "-"}
+	for _, f := range keyFiles {
+		args = append(args, "--recipient-file", f)
+	}
+	return CommandEncrypter(args...)
+}
+
+// CommandEncrypter returns an Encrypter that runs the program with
+// the command line args, which must encrypt standard input to
+// standard output.
+func CommandEncrypter(args ...string) Encrypter {
+	return commandEncrypter(args)
+}
+
+type commandEncrypter []string
+
+func (c commandEncrypter) Encrypt(w io.Writer) (io.WriteCloser, error) {
+	return startFilter(c, w)
+}
+
+// ExportEncryptedBundle is like ExportBundle, but encrypts the bundle
+// with e, so that a bundle containing logs can be sent through
+// ticketing systems and the like.  The recipient decrypts it, with
+// age -d or gpg -d for instance, before ImportBundle.
+func (r *Writer) ExportEncryptedBundle(w io.Writer, e Encrypter) error {
+	ew, err := e.Encrypt(w)
+	if err != nil {
+		return fmt.Errorf("rotate: encrypt bundle: %w", err)
+	}
+	if err := r.ExportBundle(ew); err != nil {
+		ew.Close()
+		return err
+	}
+	if err := ew.Close(); err != nil {
+		return fmt.Errorf("rotate: encrypt bundle: %w", err)
+	}
+	return nil
+}
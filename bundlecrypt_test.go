@@ -0,0 +1,82 @@
+package rotate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"testing"
+)
+
+func TestEncryptedBundle(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg not installed")
+	}
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	home := path.Join(root, "gnupg")
+	if err := os.Mkdir(home, 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GNUPGHOME", home)
+	defer exec.Command("gpgconf", "--kill", "gpg-agent").Run()
+	gen := exec.Command("gpg", "--batch", "--passphrase", "", "--quick-gen-key", "test@example.com", "default", "default", "never")
+	if out, err := gen.CombinedOutput(); err != nil {
+		t.Skipf("gpg key generation: %v: %s", err, out)
+	}
+	key := path.Join(root, "key.asc")
+	if out, err := exec.Command("gpg", "--batch", "--armor", "--output", key, "--export", "test@example.com").CombinedOutput(); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+
+	x, err := New(path.Join(root, "a"), "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(5)
+	for _, s := range []string{"one\n", "two\n", "secret\n"} {
+		if _, err := x.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := x.ExportEncryptedBundle(&buf, GPGEncrypter(key)); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("secret")) {
+		t.Fatal("bundle not encrypted")
+	}
+
+	dec := exec.Command("gpg", "--batch", "--quiet", "--decrypt")
+	dec.Stdin = &buf
+	plain, err := dec.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	y, err := New(path.Join(root, "b"), "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := y.ImportBundle(bytes.NewReader(plain)); err != nil {
+		t.Fatal(err)
+	}
+	archives, err := y.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 3 {
+		t.Fatalf("archives: %+v", archives)
+	}
+	if data, _ := ioutil.ReadFile(archives[1].Path); string(data) != "secret\n" {
+		t.Errorf("imported archive: %q", data)
+	}
+
+	if err := x.ExportEncryptedBundle(&buf, CommandEncrypter("false")); err == nil {
+		t.Error("failing encrypter: no error")
+	}
+}
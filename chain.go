@@ -0,0 +1,189 @@
+package rotate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// A ChainLink records a change to the archives in the manifest's
+// hash chain.  Each link's Hash covers the previous link's Hash, so
+// no link can be changed or removed without breaking every later
+// one.
+type ChainLink struct {
+	// Op is "add" for an archive that appeared, by rotation or
+	// compression, and "delete" for one that was removed.
+	Op string `json:"op"`
+
+	// Name is the archive's name, and SHA256 the hex encoded
+	// checksum of its contents when it was added.
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256,omitempty"`
+
+	Time time.Time `json:"time"`
+	Hash string    `json:"hash"`
+}
+
+// hash returns the link's hash, given the previous one's.
+func (l ChainLink) hash(prev string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n%s\n%s\n", prev, l.Op, l.Name, l.SHA256, l.Time.UTC().Format(time.RFC3339Nano))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SetHashChain sets whether the manifest, which must be enabled with
+// SetManifest, keeps a hash chain of every archive added and
+// deleted, for audit deployments: removing or modifying an archive
+// other than through the Writer is then found by Manifest.Verify.
+// The chain carries on from the one in an existing manifest, and is
+// started with the archives already present otherwise.  To also
+// catch the manifest itself being rewritten, record the hash of the
+// chain's last link somewhere out of reach, such as a remote log.
+func (r *Writer) SetHashChain(on bool) error {
+	r.manifestMu.Lock()
+	if !on {
+		r.chain = nil
+		r.manifestMu.Unlock()
+		return r.writeManifest()
+	}
+	if r.chain != nil {
+		r.manifestMu.Unlock()
+		return nil
+	}
+	chain := []ChainLink{}
+	if m, err := ReadManifest(r.ManifestPath()); err == nil && len(m.Chain) > 0 {
+		chain = m.Chain
+	} else {
+		archives, err := r.Archives()
+		if err != nil {
+			r.manifestMu.Unlock()
+			return fmt.Errorf("rotate: start hash chain: %w", err)
+		}
+		r.chain = chain
+		for _, a := range archives {
+			if err := r.chainAdd(a); err != nil {
+				r.chain = nil
+				r.manifestMu.Unlock()
+				return fmt.Errorf("rotate: start hash chain: %w", err)
+			}
+		}
+		chain = r.chain
+	}
+	r.chain = chain
+	r.manifestMu.Unlock()
+	return r.writeManifest()
+}
+
+// chainAdd adds a link for the archive a, if there is a hash chain.
+// manifestMu must be held.
+func (r *Writer) chainAdd(a ArchiveInfo) error {
+	if r.chain == nil {
+		return nil
+	}
+	// hash the file rather than trust its metadata, whose checksum
+	// is of the archive before any later compression
+	sum, err := fileSHA256(a.Path)
+	if err != nil {
+		return err
+	}
+	r.chainLink(ChainLink{Op: "add", Name: a.Name, SHA256: sum})
+	return nil
+}
+
+// chainLink appends l to the hash chain.  manifestMu must be held.
+func (r *Writer) chainLink(l ChainLink) {
+	prev := ""
+	if n := len(r.chain); n > 0 {
+		prev = r.chain[n-1].Hash
+	}
+	l.Time = time.Now()
+	l.Hash = l.hash(prev)
+	r.chain = append(r.chain, l)
+}
+
+// chainAdded records a new archive in the hash chain, if there is
+// one.  It does not need the lock.
+func (r *Writer) chainAdded(a ArchiveInfo) error {
+	r.manifestMu.Lock()
+	defer r.manifestMu.Unlock()
+	if err := r.chainAdd(a); err != nil {
+		return fmt.Errorf("rotate: add %s to hash chain: %w", a.Name, err)
+	}
+	return nil
+}
+
+// chainDeleted records the deletion of an archive in the hash chain,
+// if there is one.  It does not need the lock.
+func (r *Writer) chainDeleted(a ArchiveInfo) {
+	r.manifestMu.Lock()
+	defer r.manifestMu.Unlock()
+	if r.chain != nil {
+		r.chainLink(ChainLink{Op: "delete", Name: a.Name})
+	}
+}
+
+func fileSHA256(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ErrChainBroken is returned by Manifest.Verify when the archives do
+// not match the manifest's hash chain.
+var ErrChainBroken = errors.New("rotate: hash chain broken")
+
+// Verify checks the manifest's hash chain: that every link follows
+// from the one before, and that the archives the chain says are
+// present are the ones listed, with the contents they had when they
+// were added.  It returns an error wrapping ErrChainBroken naming
+// the first problem found, or nil if there is none or no chain.
+func (m *Manifest) Verify() error {
+	live := make(map[string]string)
+	prev := ""
+	for i, l := range m.Chain {
+		if l.hash(prev) != l.Hash {
+			return fmt.Errorf("%w: link %d, for %s, was altered", ErrChainBroken, i, l.Name)
+		}
+		prev = l.Hash
+		switch l.Op {
+		case "add":
+			live[l.Name] = l.SHA256
+		case "delete":
+			delete(live, l.Name)
+		default:
+			return fmt.Errorf("%w: link %d has unknown op %q", ErrChainBroken, i, l.Op)
+		}
+	}
+	if m.Chain == nil {
+		return nil
+	}
+	for _, a := range m.Archives {
+		sum, ok := live[a.Name]
+		if !ok {
+			return fmt.Errorf("%w: %s is not in the chain", ErrChainBroken, a.Name)
+		}
+		delete(live, a.Name)
+		got, err := fileSHA256(a.Path)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrChainBroken, err)
+		}
+		if got != sum {
+			return fmt.Errorf("%w: %s was modified", ErrChainBroken, a.Name)
+		}
+	}
+	for name := range live {
+		return fmt.Errorf("%w: %s is missing", ErrChainBroken, name)
+	}
+	return nil
+}
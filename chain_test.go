@@ -0,0 +1,164 @@
+package rotate
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestHashChain(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	if err := x.SetManifest(true); err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(5)
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	// the archive from before the chain starts it
+	if err := x.SetHashChain(true); err != nil {
+		t.Fatal(err)
+	}
+	x.SetKeep(2)
+	for i := 0; i < 3; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := ReadManifest(x.ManifestPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Archives) != 2 || len(m.Chain) != 6 {
+		t.Fatalf("manifest: %d archives, chain %+v", len(m.Archives), m.Chain)
+	}
+	if err := m.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(m.Archives[0].Path, []byte("hellO\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Verify(); !errors.Is(err, ErrChainBroken) {
+		t.Errorf("modified archive: %v", err)
+	}
+
+	m, _ = ReadManifest(x.ManifestPath())
+	m.Archives = m.Archives[1:]
+	if err := m.Verify(); !errors.Is(err, ErrChainBroken) {
+		t.Errorf("deleted archive: %v", err)
+	}
+
+	m, _ = ReadManifest(x.ManifestPath())
+	m.Chain = append(m.Chain[:1], m.Chain[2:]...)
+	if err := m.Verify(); !errors.Is(err, ErrChainBroken) {
+		t.Errorf("removed link: %v", err)
+	}
+}
+
+func TestHashChainImport(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(path.Join(root, "a"), "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	x.SetMax(5)
+	for _, s := range []string{"one\n", "two\n"} {
+		if _, err := x.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := x.ExportBundle(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := New(path.Join(root, "b"), "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer y.Close()
+	if err := y.SetManifest(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := y.SetHashChain(true); err != nil {
+		t.Fatal(err)
+	}
+	y.SetMax(5)
+	if _, err := y.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := y.ImportBundle(&buf); err != nil {
+		t.Fatal(err)
+	}
+	m, err := ReadManifest(y.ManifestPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Archives) != 3 {
+		t.Fatalf("archives: %+v", m.Archives)
+	}
+	if err := m.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHashChainBuckets(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt", WithTimeBuckets(time.Second, "{prefix}_{date}_{time}.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	if err := x.SetManifest(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.SetHashChain(true); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Until(bucketStart(time.Now(), time.Second).Add(time.Second)))
+	}
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	m, err := ReadManifest(x.ManifestPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Archives) != 2 {
+		t.Fatalf("archives: %+v", m.Archives)
+	}
+	if err := m.Verify(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,72 @@
+package rotate
+
+import (
+	"context"
+	"sort"
+)
+
+// CleanOptions controls Clean.
+type CleanOptions struct {
+	// DryRun makes Clean only report the archives it would
+	// delete.
+	DryRun bool
+
+	// Retention, if not nil, is used instead of the Writer's
+	// settings, so the effect of new settings can be previewed
+	// with DryRun before they are given to Update.
+	Retention *Options
+}
+
+// Clean applies the Writer's retention settings now rather than at
+// the next rotation, and returns the archives it deleted, oldest
+// first.  If ctx is cancelled, Clean stops and returns the archives
+// deleted so far.
+func (r *Writer) Clean(ctx context.Context, opts CleanOptions) ([]ArchiveInfo, error) {
+	r.Lock()
+	defer r.Unlock()
+	keep, maxAge := r.keep, r.maxAge
+	if opts.Retention != nil {
+		keep, maxAge = opts.Retention.Keep, opts.Retention.MaxAge
+	}
+	deleted, err := r.cleanArchives(ctx, keep, maxAge, opts.DryRun)
+	if len(deleted) > 0 && !opts.DryRun {
+		if merr := r.writeManifest(); err == nil {
+			err = merr
+		}
+	}
+	return deleted, err
+}
+
+// archiveLess reports whether a is older than b.
+func archiveLess(a, b ArchiveInfo) bool {
+	if a.Counter != b.Counter {
+		return a.Counter < b.Counter
+	}
+	return a.ModTime.Before(b.ModTime)
+}
+
+// sortArchives sorts archives oldest first.
+func sortArchives(archives []ArchiveInfo) {
+	sort.Slice(archives, func(i, j int) bool {
+		return archiveLess(archives[i], archives[j])
+	})
+}
+
+// archiveHeap is a container/heap of archives with the oldest on
+// top.
+type archiveHeap []ArchiveInfo
+
+func (h archiveHeap) Len() int           { return len(h) }
+func (h archiveHeap) Less(i, j int) bool { return archiveLess(h[i], h[j]) }
+func (h archiveHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *archiveHeap) Push(x interface{}) {
+	*h = append(*h, x.(ArchiveInfo))
+}
+
+func (h *archiveHeap) Pop() interface{} {
+	old := *h
+	a := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return a
+}
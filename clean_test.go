@@ -0,0 +1,121 @@
+package rotate
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestClean(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(1)
+	for i := 0; i < 5; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	o := x.Options()
+	o.Keep = 2
+	deleted, err := x.Clean(context.Background(), CleanOptions{DryRun: true, Retention: &o})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 3 || deleted[0].Name != "mt_1" || deleted[2].Name != "mt_3" {
+		t.Errorf("dry run: %+v, expected mt_1 to mt_3", deleted)
+	}
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 5 {
+		t.Errorf("archives after dry run: %d, expected 5", len(archives))
+	}
+
+	if err := x.Update(o); err != nil {
+		t.Fatal(err)
+	}
+	archives, err = x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 2 {
+		t.Errorf("archives: %d, expected 2", len(archives))
+	}
+}
+
+func TestKeepAll(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(1)
+	x.SetKeep(KeepAll)
+	for i := 0; i < 15; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deleted, err := x.Clean(context.Background(), CleanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 0 {
+		t.Errorf("deleted %d archives, expected none", len(deleted))
+	}
+	x.Close()
+	if archives, _ := x.Archives(); len(archives) != 15 {
+		t.Errorf("archives: %d, expected 15", len(archives))
+	}
+}
+
+func TestCleanLargeDir(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	// more archives than one directory read returns
+	n := scanBatch + 100
+	for i := 1; i <= n; i++ {
+		if err := ioutil.WriteFile(path.Join(root, fmt.Sprintf("mt_%d", i)), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != keepDefault {
+		t.Fatalf("%d archives left, expected %d", len(archives), keepDefault)
+	}
+	for i, a := range archives {
+		if want := n - keepDefault + 1 + i; a.Counter != want {
+			t.Errorf("archive %d: counter %d, expected %d", i, a.Counter, want)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+// Command file-rotate works with directories of logs written by
+// stathat.com/c/rotate.
+//
+// Usage:
+//
+//	file-rotate prune --root DIR --prefix P [--keep N] [--max-age AGE] [--compress] [--dry-run]
+//	file-rotate tail --root DIR --prefix P [-n N] [-f]
+//	file-rotate serve --root DIR --prefix P [--listen ADDR] [--max SIZE] [--keep N] [--max-age AGE]
+//	file-rotate repair [--save-partial] [--dry-run] FILE...
+//
+// prune applies retention and compression to the archives in DIR
+// offline, as a Writer would at its next rotation.
+//
+// tail prints the last lines of the logs, reaching back into the
+// archives if needed, and with -f keeps printing what is written,
+// across rotations, until interrupted.
+//
+// serve receives lines over the network and writes them to rotated
+// logs.  ADDR is tcp:HOST:PORT or unix:PATH.  When it is started by
+// systemd socket activation, it serves the sockets it is passed
+// instead, so it can be started on demand and restarted without
+// clients ever finding the socket closed.
+//
+// repair truncates log files whose final record was torn by a crash
+// to their last complete line.  It must not be run on a file that
+// is being written.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	"stathat.com/c/rotate"
+)
+
+const usage = `usage: file-rotate <command> [flags]
+
+commands:
+  prune    delete old archives and compress the rest
+  tail     print the last lines of the logs, optionally following them
+  serve    write lines received over the network to rotated logs
+  repair   truncate a torn final record left by a crash
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "prune":
+		err = prune(os.Args[2:])
+	case "tail":
+		err = tail(os.Args[2:])
+	case "serve":
+		err = serve(os.Args[2:])
+	case "repair":
+		err = repair(os.Args[2:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "file-rotate: unknown command %q\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "file-rotate:", err)
+		os.Exit(1)
+	}
+}
+
+// layoutFlags adds the flags that describe how a directory of logs
+// is laid out.
+func layoutFlags(fs *flag.FlagSet, o *rotate.Options) (root, prefix *string) {
+	root = fs.String("root", "", "directory of the logs")
+	prefix = fs.String("prefix", "", "prefix of the archive names")
+	fs.StringVar(&o.NamePattern, "pattern", o.NamePattern, "pattern of the archive names")
+	fs.BoolVar(&o.DatedDirs, "dated", o.DatedDirs, "archives are in dated subdirectories")
+	fs.StringVar(&o.ArchiveDir, "archive-dir", o.ArchiveDir, "directory of the archives, if not root")
+	return root, prefix
+}
+
+// maxAgeFlag adds the --max-age flag, which takes ages such as 7d.
+func maxAgeFlag(fs *flag.FlagSet, o *rotate.Options) {
+	fs.Func("max-age", "age, such as 7d or 4w, after which archives are deleted", func(s string) error {
+		d, err := rotate.ParseAge(s)
+		if err != nil {
+			return err
+		}
+		o.MaxAge = d
+		return nil
+	})
+}
+
+func prune(args []string) error {
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	o := rotate.DefaultOptions()
+	root, prefix := layoutFlags(fs, &o)
+	fs.IntVar(&o.Keep, "keep", o.Keep, "number of archives to keep")
+	maxAgeFlag(fs, &o)
+	compress := fs.Bool("compress", false, "gzip the archives that are kept")
+	dryRun := fs.Bool("dry-run", false, "only print what would be done")
+	fs.Parse(args)
+	if *root == "" || *prefix == "" {
+		return fmt.Errorf("prune: --root and --prefix are required")
+	}
+
+	opts := rotate.PruneOptions{Options: o, DryRun: *dryRun}
+	if *compress {
+		opts.Compress = rotate.Gzip
+	}
+	res, err := rotate.Prune(context.Background(), *root, *prefix, opts)
+	deleted, compressed := "deleted", "compressed"
+	if *dryRun {
+		deleted, compressed = "would delete", "would compress"
+	}
+	for _, a := range res.Deleted {
+		fmt.Println(deleted, a.Path)
+	}
+	for _, a := range res.Compressed {
+		fmt.Println(compressed, a.Path)
+	}
+	return err
+}
+
+func tail(args []string) error {
+	fs := flag.NewFlagSet("tail", flag.ExitOnError)
+	o := rotate.DefaultOptions()
+	root, prefix := layoutFlags(fs, &o)
+	n := fs.Int("n", 10, "number of lines to print")
+	follow := fs.Bool("f", false, "keep printing lines as they are written")
+	file := fs.String("file", "", "name of the current file, if not the default")
+	fs.Parse(args)
+	if *root == "" || *prefix == "" {
+		return fmt.Errorf("tail: --root and --prefix are required")
+	}
+
+	fo := rotate.FollowOptions{Options: o, FileName: *file}
+	if !*follow {
+		b, err := rotate.Tail(*root, *prefix, *n, fo)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	rd, err := rotate.Follow(ctx, *root, *prefix, *n, fo)
+	if err != nil {
+		return err
+	}
+	defer rd.Close()
+	if _, err := io.Copy(os.Stdout, rd); err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
+}
+
+func serve(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	o := rotate.DefaultOptions()
+	root, prefix := layoutFlags(fs, &o)
+	listen := fs.String("listen", "tcp:localhost:5140", "address to listen on when not socket activated")
+	fs.Func("max", "size at which the current file is rotated, such as 100MB or 1GiB (default 8MiB)", func(s string) error {
+		n, err := rotate.ParseSize(s)
+		if err != nil {
+			return err
+		}
+		o.Max = int(n)
+		return nil
+	})
+	fs.IntVar(&o.Keep, "keep", o.Keep, "number of archives to keep")
+	maxAgeFlag(fs, &o)
+	fs.Parse(args)
+	if *root == "" || *prefix == "" {
+		return fmt.Errorf("serve: --root and --prefix are required")
+	}
+
+	ls, err := rotate.SystemdListeners()
+	if err != nil {
+		return err
+	}
+	if ls == nil {
+		network, addr, ok := strings.Cut(*listen, ":")
+		if !ok {
+			return fmt.Errorf("serve: bad address %q", *listen)
+		}
+		l, err := net.Listen(network, addr)
+		if err != nil {
+			return err
+		}
+		ls = append(ls, l)
+	}
+
+	w, err := rotate.New(*root, *prefix, rotate.WithOptions(o))
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errs := make(chan error, len(ls))
+	var wg sync.WaitGroup
+	for _, l := range ls {
+		wg.Add(1)
+		go func(l net.Listener) {
+			defer wg.Done()
+			if err := rotate.Serve(ctx, l, w); err != nil {
+				errs <- err
+				stop()
+			}
+		}(l)
+	}
+	wg.Wait()
+	close(errs)
+	err = <-errs
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func repair(args []string) error {
+	fs := flag.NewFlagSet("repair", flag.ExitOnError)
+	var o rotate.RepairOptions
+	fs.BoolVar(&o.SavePartial, "save-partial", false, "save the torn record to FILE.partial")
+	fs.BoolVar(&o.DryRun, "dry-run", false, "only print what would be done")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return fmt.Errorf("repair: no files given")
+	}
+
+	for _, p := range fs.Args() {
+		res, err := rotate.Repair(p, o)
+		if err != nil {
+			return err
+		}
+		if res.Torn == 0 {
+			continue
+		}
+		if o.DryRun {
+			fmt.Printf("%s: would remove %d bytes of a torn record\n", p, res.Torn)
+			continue
+		}
+		fmt.Printf("%s: removed %d bytes of a torn record\n", p, res.Torn)
+		if res.Partial != "" {
+			fmt.Printf("%s: saved them to %s\n", p, res.Partial)
+		}
+	}
+	return nil
+}
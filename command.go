@@ -0,0 +1,132 @@
+package rotate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// XZ is a Compressor using the xz program at its highest level, for
+// archives where size matters more than CPU, such as those shipped
+// over slow links.  xz must be in the PATH.
+var XZ = CommandCompressor(".xz", []string{"xz", "-9", "-c"}, []string{"xz", "-d", "-c"})
+
+func init() {
+	RegisterCompressor("xz", XZ)
+}
+
+// CommandCompressor returns a Compressor that runs external programs,
+// for formats the standard library does not have.  compress and
+// decompress are the command lines of programs that filter standard
+// input to standard output; ext is the extension of the compressed
+// files.  Decompressing a file a Writer appended to after a restart
+// must handle concatenated streams, as xz, zstd and lz4 do.
+func CommandCompressor(ext string, compress, decompress []string) Compressor {
+	return &commandCompressor{ext: ext, compress: compress, decompress: decompress}
+}
+
+type commandCompressor struct {
+	ext                  string
+	compress, decompress []string
+}
+
+func (c *commandCompressor) Ext() string { return c.ext }
+
+func (c *commandCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return startFilter(c.compress, w)
+}
+
+// startFilter starts the program with the command line args, and
+// returns its standard input; its standard output goes to w.
+func startFilter(args []string, w io.Writer) (io.WriteCloser, error) {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = w
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &commandWriter{WriteCloser: in, cmd: cmd, stderr: stderr}, nil
+}
+
+func (c *commandCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	cmd := exec.Command(c.decompress[0], c.decompress[1:]...)
+	cmd.Stdin = r
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &commandReader{out: out, cmd: cmd, stderr: stderr}, nil
+}
+
+// commandError returns the error of cmd, with what it printed.
+func commandError(cmd *exec.Cmd, err error, stderr *bytes.Buffer) error {
+	msg := strings.TrimSpace(stderr.String())
+	if msg == "" {
+		return fmt.Errorf("%s: %w", cmd.Path, err)
+	}
+	return fmt.Errorf("%s: %w: %s", cmd.Path, err, msg)
+}
+
+// commandWriter writes to the standard input of a compressing
+// program.
+type commandWriter struct {
+	io.WriteCloser
+	cmd    *exec.Cmd
+	stderr *bytes.Buffer
+}
+
+// Close ends the input and waits for the program to write the rest
+// of its output.
+func (w *commandWriter) Close() error {
+	w.WriteCloser.Close()
+	if err := w.cmd.Wait(); err != nil {
+		return commandError(w.cmd, err, w.stderr)
+	}
+	return nil
+}
+
+// commandReader reads the standard output of a decompressing
+// program.
+type commandReader struct {
+	out    io.ReadCloser
+	cmd    *exec.Cmd
+	stderr *bytes.Buffer
+	done   bool
+}
+
+// Read reads the program's output.  At the end of it, a failure of
+// the program, such as on corrupt input, is returned instead of
+// io.EOF.
+func (r *commandReader) Read(p []byte) (int, error) {
+	n, err := r.out.Read(p)
+	if err == io.EOF && !r.done {
+		r.done = true
+		if werr := r.cmd.Wait(); werr != nil {
+			return n, commandError(r.cmd, werr, r.stderr)
+		}
+	}
+	return n, err
+}
+
+// Close stops the program if it has not finished.
+func (r *commandReader) Close() error {
+	if r.done {
+		return nil
+	}
+	r.done = true
+	r.cmd.Process.Kill()
+	r.cmd.Wait()
+	return nil
+}
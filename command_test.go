@@ -0,0 +1,65 @@
+package rotate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"testing"
+)
+
+func TestXZ(t *testing.T) {
+	if _, err := exec.LookPath("xz"); err != nil {
+		t.Skip("no xz")
+	}
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt", WithLiveCompression(XZ))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(5)
+	for _, s := range []string{"hello\n", "world\n"} {
+		if _, err := x.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{"mt_1.xz": "hello\n", "mt_2.xz": "world\n"} {
+		f, err := os.Open(path.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rc, err := XZ.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(rc)
+		rc.Close()
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("%s: %q, expected %q", name, b, want)
+		}
+	}
+
+	// corrupt input is an error, not a short file
+	rc, err := XZ.NewReader(bytes.NewReader([]byte("not xz")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	if _, err := ioutil.ReadAll(rc); err == nil {
+		t.Error("no error for corrupt input")
+	}
+}
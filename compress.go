@@ -0,0 +1,86 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"io"
+	"strings"
+)
+
+// A Compressor compresses log files.
+type Compressor interface {
+	// Ext returns the extension added to compressed file
+	// names, such as ".gz".
+	Ext() string
+
+	// NewWriter returns a writer that compresses to w.  Closing
+	// it must flush all data but not close w.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+
+	// NewReader returns a reader that decompresses r.
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// Gzip is a Compressor using gzip with the default compression
+// level.
+var Gzip Compressor = gzipCompressor{}
+
+// compressors are the Compressors used to read archives, chosen by
+// file name extension.
+var compressors = []Compressor{Gzip}
+
+// compressorFor returns the Compressor whose extension name has, or
+// nil.
+func compressorFor(name string) Compressor {
+	for _, c := range compressors {
+		if strings.HasSuffix(name, c.Ext()) {
+			return c
+		}
+	}
+	return nil
+}
+
+// knownExt returns whether ext is that of a Compressor in
+// compressors, possibly Encrypted, which archive names may end with.
+func knownExt(ext string) bool {
+	ext = strings.TrimSuffix(ext, encExt)
+	if ext == "" {
+		return true
+	}
+	for _, c := range compressors {
+		if c.Ext() == ext {
+			return true
+		}
+	}
+	return false
+}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Ext() string { return ".gz" }
+
+func (gzipCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+func (gzipCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// WithLiveCompression makes the Writer compress the current file as
+// it is written, using c, so no uncompressed file is ever stored.
+// The current file is named with c's extension, as default.log.gz,
+// and archives are renamed as they are, as prefix_N.gz.
+//
+// Data is buffered by the compressor, so it only reaches the disk
+// in blocks and the end of the current file is not readable until
+// it is rotated or closed.  The maximum size applies to the
+// uncompressed data.
+func WithLiveCompression(c Compressor) Option {
+	return func(r *Writer) {
+		r.live = c
+		r.liveNext = c
+		if e, ok := c.(*encryptedCompressor); ok && r.keys == nil {
+			r.keys = e.kp
+		}
+	}
+}
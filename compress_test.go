@@ -0,0 +1,74 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func readGzip(t *testing.T, p string) string {
+	f, err := os.Open(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestLiveCompression(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt", WithLiveCompression(Gzip))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(5)
+	for _, s := range []string{"hello\n", "world\n", "x"} {
+		if _, err := x.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a second run appends another gzip member to the current file
+	x, err = New(root, "mt", WithLiveCompression(Gzip))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.Write([]byte("y")); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := readGzip(t, path.Join(root, "mt_1.gz")); s != "hello\n" {
+		t.Errorf("mt_1.gz: %q, expected %q", s, "hello\n")
+	}
+	if s := readGzip(t, path.Join(root, "default.log.gz")); s != "xy" {
+		t.Errorf("default.log.gz: %q, expected %q", s, "xy")
+	}
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 2 || archives[1].Name != "mt_2.gz" || archives[1].Counter != 2 {
+		t.Errorf("archives: %+v", archives)
+	}
+}
@@ -0,0 +1,84 @@
+package rotate
+
+import (
+	"fmt"
+	"time"
+)
+
+// CompressionPolicy compresses archives some time after rotation,
+// so the most recent ones stay uncompressed for fast grepping while
+// older ones take less disk.
+type CompressionPolicy struct {
+	// Compressor compresses the archives.  Nil turns the policy
+	// off, which is the default.
+	Compressor Compressor
+
+	// KeepUncompressed is the number of the newest archives left
+	// uncompressed.  It must not be negative.
+	KeepUncompressed int
+
+	// MinAge, if not zero, leaves archives last written less than
+	// MinAge ago uncompressed too.
+	MinAge time.Duration
+}
+
+// SetCompressionPolicy sets the policy for compressing archives.  It
+// is applied after every rotation, once retention has run, and during
+// the quiet window only, if SetQuietWindow set one.  It does not
+// affect live compression.  The Compressor must be Gzip, one given to
+// RegisterCompressor or one of those Encrypted, so the compressed
+// archives are still recognized as archives.
+func (r *Writer) SetCompressionPolicy(p CompressionPolicy) error {
+	if p.KeepUncompressed < 0 {
+		return fmt.Errorf("rotate: compression policy: negative KeepUncompressed %d", p.KeepUncompressed)
+	}
+	if p.Compressor != nil && !knownExt(p.Compressor.Ext()) {
+		return fmt.Errorf("rotate: compression policy: unregistered compressor for %s files", p.Compressor.Ext())
+	}
+	r.Lock()
+	r.compression = p
+	r.Unlock()
+	return nil
+}
+
+// compressOld compresses the archives the compression policy asks
+// for and returns them by the path they had before.  The lock must be
+// held.
+func (r *Writer) compressOld() (map[string]ArchiveInfo, error) {
+	p := r.compression
+	now := time.Now()
+	if p.Compressor == nil || !r.inWindow(now) {
+		return nil, nil
+	}
+	archives, err := r.archives()
+	if err != nil {
+		return nil, fmt.Errorf("rotate: list archives: %w", err)
+	}
+	if len(archives) <= p.KeepUncompressed {
+		return nil, nil
+	}
+	compressed := make(map[string]ArchiveInfo)
+	for _, a := range archives[:len(archives)-p.KeepUncompressed] {
+		if compressorFor(a.Name) != nil || isEncrypted(a.Name) || now.Sub(a.ModTime) < p.MinAge {
+			continue
+		}
+		r.unprotectArchive(a.Path)
+		old := a
+		region("compress", func() { a, err = compressArchive(a, p.Compressor, r.filePerm) })
+		if err != nil {
+			r.protectArchive(a.Path)
+			return compressed, fmt.Errorf("rotate: compress archive: %w", err)
+		}
+		compressed[old.Path] = a
+		r.chainDeleted(old)
+		if err := r.chainAdded(a); err != nil {
+			return compressed, err
+		}
+		if err := r.protectArchive(a.Path); err != nil {
+			return compressed, err
+		}
+		r.logf("compressed %s", a.Path)
+		r.emit(Event{Kind: EventCompressed, Archive: a})
+	}
+	return compressed, nil
+}
@@ -0,0 +1,157 @@
+package rotate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCompressionPolicy(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	x.SetMax(6)
+	x.SetSidecar(true)
+	x.SetReadOnlyArchives(true)
+	if err := x.SetCompressionPolicy(CompressionPolicy{Compressor: CommandCompressor(".lz", []string{"lzip"}, nil)}); err == nil {
+		t.Error("unregistered compressor accepted")
+	}
+	if err := x.SetCompressionPolicy(CompressionPolicy{Compressor: Gzip, KeepUncompressed: -1}); err == nil {
+		t.Error("negative KeepUncompressed accepted")
+	}
+	if err := x.SetCompressionPolicy(CompressionPolicy{Compressor: Gzip, KeepUncompressed: 2}); err != nil {
+		t.Fatal(err)
+	}
+	events := x.Events()
+	for i := 0; i < 4; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"mt_1.gz", "mt_2.gz", "mt_3", "mt_4"}
+	if len(archives) != len(want) {
+		t.Fatalf("archives: %+v", archives)
+	}
+	for i, a := range archives {
+		if a.Name != want[i] {
+			t.Errorf("archive %d: %s, expected %s", i, a.Name, want[i])
+		}
+		if a.Meta == nil {
+			t.Errorf("%s: no metadata", a.Name)
+		}
+		if fi, err := os.Stat(a.Path); err != nil || fi.Mode().Perm() != archivePerm {
+			t.Errorf("%s: not read-only: %v", a.Name, err)
+		}
+	}
+	compressed := 0
+	for len(events) > 0 {
+		if e := <-events; e.Kind == EventCompressed {
+			compressed++
+		}
+	}
+	if compressed != 2 {
+		t.Errorf("%d compressed events", compressed)
+	}
+}
+
+func TestCompressionPolicyKeepsAge(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	x.SetMax(6)
+	if err := x.SetCompressionPolicy(CompressionPolicy{Compressor: Gzip, MinAge: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path.Join(root, "mt_1"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 2 || archives[0].Name != "mt_1.gz" || archives[1].Name != "mt_2" {
+		t.Fatalf("archives: %+v", archives)
+	}
+	if !archives[0].ModTime.Equal(old) {
+		t.Errorf("compressed archive modified at %v, expected %v", archives[0].ModTime, old)
+	}
+}
+
+type readSink struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (s *readSink) Store(ctx context.Context, path string, info ArchiveInfo) error {
+	if _, err := ioutil.ReadFile(path); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.names = append(s.names, info.Name)
+	s.mu.Unlock()
+	return nil
+}
+
+func TestCompressionPolicySink(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(6)
+	sink := new(readSink)
+	x.SetSink(sink)
+	x.SetErrorHandler(func(err error) { t.Error(err) })
+	if err := x.SetCompressionPolicy(CompressionPolicy{Compressor: Gzip}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(sink.names)
+	if len(sink.names) != 2 || sink.names[0] != "mt_1.gz" || sink.names[1] != "mt_2.gz" {
+		t.Errorf("stored: %v, expected [mt_1.gz mt_2.gz]", sink.names)
+	}
+}
@@ -0,0 +1,291 @@
+package rotate
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Config is the settings of Options in a form that can be written
+// to and read from configuration files and admin APIs: sizes and ages
+// are written the way ParseSize and ParseAge read them, the
+// compressor by name, and KeepAll as "all".  It can be encoded as
+// JSON or YAML and decoded back unchanged.
+//
+// Settings missing when a Config is decoded take their default
+// values, as do a zero Max and an empty NamePattern when a Config is
+// turned into Options; a Keep of zero keeps no archives.
+type Config struct {
+	Max         int64         `json:"max" yaml:"max"`
+	Keep        int           `json:"keep" yaml:"keep"`
+	MaxAge      time.Duration `json:"max_age" yaml:"max_age"`
+	Compression string        `json:"compression" yaml:"compression"`
+	NamePattern string        `json:"name_pattern" yaml:"name_pattern"`
+	DatedDirs   bool          `json:"dated_dirs" yaml:"dated_dirs"`
+	ArchiveDir  string        `json:"archive_dir" yaml:"archive_dir"`
+}
+
+// compressorNames are the compressors known by name to Config.
+var compressorNames = map[string]Compressor{
+	"gzip": Gzip,
+}
+
+// RegisterCompressor makes c known by name to Config, and lets
+// archives compressed with it be read.  It must be called before
+// Writers are created, from an init function for instance.
+func RegisterCompressor(name string, c Compressor) {
+	compressorNames[name] = c
+	compressors = append(compressors, c)
+}
+
+// compressorName returns the name of c in a Config.  A compressor not
+// known by name is named after its extension.
+func compressorName(c Compressor) string {
+	if c == nil {
+		return ""
+	}
+	for name, k := range compressorNames {
+		if k.Ext() == c.Ext() {
+			return name
+		}
+	}
+	return strings.TrimPrefix(c.Ext(), ".")
+}
+
+// Config returns o as a Config.
+func (o Options) Config() Config {
+	return Config{
+		Max:         int64(o.Max),
+		Keep:        o.Keep,
+		MaxAge:      o.MaxAge,
+		Compression: compressorName(o.Compression),
+		NamePattern: o.NamePattern,
+		DatedDirs:   o.DatedDirs,
+		ArchiveDir:  o.ArchiveDir,
+	}
+}
+
+// Options returns c as Options, with the defaults of DefaultOptions
+// for a zero Max and an empty NamePattern.  It fails if the
+// compressor is not known by name.
+func (c Config) Options() (Options, error) {
+	o := DefaultOptions()
+	if c.Max != 0 {
+		if c.Max < 0 || c.Max > math.MaxInt {
+			return o, fmt.Errorf("rotate: bad maximum size %d", c.Max)
+		}
+		o.Max = int(c.Max)
+	}
+	if c.NamePattern != "" {
+		o.NamePattern = c.NamePattern
+	}
+	o.Keep = c.Keep
+	o.MaxAge = c.MaxAge
+	o.DatedDirs = c.DatedDirs
+	o.ArchiveDir = c.ArchiveDir
+	if c.Compression != "" {
+		comp, ok := compressorNames[c.Compression]
+		if !ok {
+			return o, fmt.Errorf("rotate: unknown compression %q", c.Compression)
+		}
+		o.Compression = comp
+	}
+	return o, nil
+}
+
+// WithConfig makes New start the Writer with the settings c, as
+// WithOptions does with Options.
+func WithConfig(c Config) Option {
+	return func(r *Writer) {
+		o, err := c.Options()
+		if err != nil {
+			r.optErr = err
+			return
+		}
+		WithOptions(o)(r)
+	}
+}
+
+// Config returns the Writer's current settings as a Config.
+func (r *Writer) Config() Config {
+	return r.Options().Config()
+}
+
+// String returns the settings on one line, for logs.
+func (c Config) String() string {
+	return fmt.Sprintf("max=%s keep=%d max_age=%s compression=%q name_pattern=%q dated_dirs=%t archive_dir=%q",
+		formatSize(c.Max), c.Keep, formatAge(c.MaxAge), c.Compression, c.NamePattern, c.DatedDirs, c.ArchiveDir)
+}
+
+// configDoc is the encoded form of a Config.  Max, Keep and MaxAge
+// can be numbers or strings, and are nil when missing.
+type configDoc struct {
+	Max         interface{} `json:"max" yaml:"max"`
+	Keep        interface{} `json:"keep" yaml:"keep"`
+	MaxAge      interface{} `json:"max_age" yaml:"max_age"`
+	Compression string      `json:"compression" yaml:"compression"`
+	NamePattern string      `json:"name_pattern" yaml:"name_pattern"`
+	DatedDirs   bool        `json:"dated_dirs" yaml:"dated_dirs"`
+	ArchiveDir  string      `json:"archive_dir" yaml:"archive_dir"`
+}
+
+// doc returns c in its encoded form.
+func (c Config) doc() configDoc {
+	var keep interface{} = c.Keep
+	if c.Keep == KeepAll {
+		keep = "all"
+	}
+	return configDoc{
+		Max:         formatSize(c.Max),
+		Keep:        keep,
+		MaxAge:      formatAge(c.MaxAge),
+		Compression: c.Compression,
+		NamePattern: c.NamePattern,
+		DatedDirs:   c.DatedDirs,
+		ArchiveDir:  c.ArchiveDir,
+	}
+}
+
+// fromDoc sets c from its encoded form, with the defaults for the
+// settings missing from it.
+func (c *Config) fromDoc(d configDoc) error {
+	*c = DefaultOptions().Config()
+	if d.Max != nil {
+		n, err := docInt(d.Max, ParseSize)
+		if err != nil {
+			return fmt.Errorf("rotate: config: max: %w", err)
+		}
+		c.Max = n
+	}
+	if d.Keep != nil {
+		n, err := docInt(d.Keep, parseKeep)
+		if err != nil {
+			return fmt.Errorf("rotate: config: keep: %w", err)
+		}
+		c.Keep = int(n)
+	}
+	if d.MaxAge != nil {
+		var err error
+		if s, ok := d.MaxAge.(string); ok {
+			c.MaxAge, err = ParseAge(s)
+		} else {
+			// a bare number is in seconds
+			var n int64
+			n, err = docInt(d.MaxAge, nil)
+			c.MaxAge = time.Duration(n) * time.Second
+		}
+		if err != nil {
+			return fmt.Errorf("rotate: config: max_age: %w", err)
+		}
+	}
+	c.Compression = d.Compression
+	if d.NamePattern != "" {
+		c.NamePattern = d.NamePattern
+	}
+	c.DatedDirs = d.DatedDirs
+	c.ArchiveDir = d.ArchiveDir
+	return nil
+}
+
+// docInt returns v, a decoded number or a string parsed by parse, as
+// an integer.
+func docInt(v interface{}, parse func(string) (int64, error)) (int64, error) {
+	switch v := v.(type) {
+	case string:
+		return parse(v)
+	case float64:
+		if v != math.Trunc(v) || math.Abs(v) >= math.MaxInt64 {
+			return 0, fmt.Errorf("bad number %v", v)
+		}
+		return int64(v), nil
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, fmt.Errorf("bad number %v", v)
+		}
+		return int64(v), nil
+	}
+	return 0, fmt.Errorf("bad value %v", v)
+}
+
+// parseKeep parses the number of archives to keep, or "all".
+func parseKeep(s string) (int64, error) {
+	if s == "all" {
+		return KeepAll, nil
+	}
+	return strconv.ParseInt(s, 10, 0)
+}
+
+// MarshalJSON encodes c with the field names given by its tags, and
+// max and max_age as strings such as "8MiB" and "7d".
+func (c Config) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.doc())
+}
+
+// UnmarshalJSON decodes c, taking the defaults for missing settings.
+func (c *Config) UnmarshalJSON(b []byte) error {
+	var d configDoc
+	if err := json.Unmarshal(b, &d); err != nil {
+		return err
+	}
+	return c.fromDoc(d)
+}
+
+// MarshalYAML encodes c as MarshalJSON does, for YAML packages that
+// look for this method, such as gopkg.in/yaml.v3.
+func (c Config) MarshalYAML() (interface{}, error) {
+	return c.doc(), nil
+}
+
+// UnmarshalYAML decodes c as UnmarshalJSON does, for YAML packages
+// that look for this method.
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var d configDoc
+	if err := unmarshal(&d); err != nil {
+		return err
+	}
+	return c.fromDoc(d)
+}
+
+// formatSize returns n in the largest unit that ParseSize reads back
+// exactly.
+func formatSize(n int64) string {
+	if n == 0 {
+		return "0"
+	}
+	for _, u := range []struct {
+		name string
+		size int64
+	}{
+		{"TiB", 1 << 40}, {"TB", 1e12},
+		{"GiB", 1 << 30}, {"GB", 1e9},
+		{"MiB", 1 << 20}, {"MB", 1e6},
+		{"KiB", 1 << 10}, {"kB", 1e3},
+	} {
+		if n%u.size == 0 {
+			return strconv.FormatInt(n/u.size, 10) + u.name
+		}
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// formatAge returns d in weeks or days if it is a whole number of
+// them, as ParseAge reads it.
+func formatAge(d time.Duration) string {
+	const day = 24 * time.Hour
+	switch {
+	case d == 0:
+		return "0s"
+	case d%(7*day) == 0:
+		return strconv.FormatInt(int64(d/(7*day)), 10) + "w"
+	case d%day == 0:
+		return strconv.FormatInt(int64(d/day), 10) + "d"
+	}
+	return d.String()
+}
@@ -0,0 +1,95 @@
+package rotate
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestConfigJSON(t *testing.T) {
+	o := DefaultOptions()
+	o.MaxAge = 14 * 24 * time.Hour
+	o.Compression = Gzip
+	c := o.Config()
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"max":"8MiB","keep":10,"max_age":"2w","compression":"gzip","name_pattern":"{prefix}_{n}","dated_dirs":false,"archive_dir":""}`
+	if string(b) != want {
+		t.Errorf("got  %s\nwant %s", b, want)
+	}
+	s := `max=8MiB keep=10 max_age=2w compression="gzip" name_pattern="{prefix}_{n}" dated_dirs=false archive_dir=""`
+	if c.String() != s {
+		t.Errorf("got  %s\nwant %s", c, s)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for _, n := range []int64{0, 1, 1000, 1024, 1500, 100e6, 3 << 29} {
+		s := formatSize(n)
+		m, err := ParseSize(s)
+		if err != nil || m != n {
+			t.Errorf("%d: %q parsed as %d, %v", n, s, m, err)
+		}
+	}
+}
+
+func TestConfigRoundTrip(t *testing.T) {
+	for _, c := range []Config{
+		DefaultOptions().Config(),
+		{Max: 100e6, Keep: KeepAll, MaxAge: 90 * time.Minute, Compression: "gzip", NamePattern: "{prefix}-{n}", DatedDirs: true, ArchiveDir: "old"},
+		{Max: 1500, Keep: 0, MaxAge: 3 * 24 * time.Hour, NamePattern: namePatternDefault},
+	} {
+		b, err := json.Marshal(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Config
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatalf("%s: %v", b, err)
+		}
+		if got != c {
+			t.Errorf("%s decoded as %+v", b, got)
+		}
+
+		// as a YAML package would
+		var yc Config
+		err = yc.UnmarshalYAML(func(v interface{}) error {
+			d, _ := c.MarshalYAML()
+			*v.(*configDoc) = d.(configDoc)
+			return nil
+		})
+		if err != nil || yc != c {
+			t.Errorf("YAML: %+v, %v", yc, err)
+		}
+	}
+}
+
+func TestConfigDefaults(t *testing.T) {
+	var c Config
+	if err := json.Unmarshal([]byte(`{"max": 1048576, "max_age": "7d", "compression": "gzip"}`), &c); err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultOptions().Config()
+	want.Max = 1 << 20
+	want.MaxAge = 7 * 24 * time.Hour
+	want.Compression = "gzip"
+	if c != want {
+		t.Errorf("got %+v", c)
+	}
+	o, err := c.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Max != 1<<20 || o.Keep != keepDefault || o.Compression != Gzip {
+		t.Errorf("options: %+v", o)
+	}
+
+	if err := json.Unmarshal([]byte(`{"keep": "some"}`), &c); err == nil {
+		t.Error("bad keep accepted")
+	}
+	if _, err := (Config{Compression: "lz9"}).Options(); err == nil {
+		t.Error("unknown compression accepted")
+	}
+}
@@ -0,0 +1,147 @@
+package rotate
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+)
+
+// A Coordinator enforces a retention budget shared by several
+// Writers, typically with different prefixes in the same root.  When
+// any of them rotates, the oldest archives across all of them are
+// deleted until the total is within the budget.  Sizes are measured
+// on disk, so compressed archives count at their compressed size.
+// Each Writer's own keep limit still applies.
+type Coordinator struct {
+	mu       sync.Mutex
+	maxBytes int64
+	maxFiles int
+	writers  []*Writer
+
+	// sem holds a token for each Writer rotating, if the number
+	// is bounded.
+	sem chan struct{}
+}
+
+// NewCoordinator creates a Coordinator that keeps at most maxBytes
+// bytes in at most maxFiles archives.  A limit of zero is not
+// enforced.
+func NewCoordinator(maxBytes int64, maxFiles int) *Coordinator {
+	return &Coordinator{maxBytes: maxBytes, maxFiles: maxFiles}
+}
+
+// SetMaxConcurrent bounds the number of the Writers that rotate at
+// once to n, so Writers that all rotate together, after midnight
+// for instance, take turns at the disk rather than flood it with
+// copies, checksums and deletions.  A Writer waiting for its turn
+// holds its lock, so writes to it wait too.  Zero, the default,
+// lifts the bound.
+func (c *Coordinator) SetMaxConcurrent(n int) {
+	c.mu.Lock()
+	c.sem = nil
+	if n > 0 {
+		c.sem = make(chan struct{}, n)
+	}
+	c.mu.Unlock()
+}
+
+// acquire waits for a Writer's turn to rotate, and returns the
+// function that ends it.
+func (c *Coordinator) acquire() func() {
+	c.mu.Lock()
+	sem := c.sem
+	c.mu.Unlock()
+	if sem == nil {
+		return func() {}
+	}
+	sem <- struct{}{}
+	return func() { <-sem }
+}
+
+// Add places w under the coordinator's budget.
+func (c *Coordinator) Add(w *Writer) {
+	c.mu.Lock()
+	c.writers = append(c.writers, w)
+	c.mu.Unlock()
+	// A rotating Writer holds its lock while it takes c.mu, so
+	// the two must not be held together here.
+	w.Lock()
+	w.coord = c
+	w.Unlock()
+}
+
+// Remove takes w out from under the coordinator's budget.
+func (c *Coordinator) Remove(w *Writer) {
+	c.mu.Lock()
+	for i, x := range c.writers {
+		if x == w {
+			c.writers = append(c.writers[:i], c.writers[i+1:]...)
+			break
+		}
+	}
+	c.mu.Unlock()
+	w.Lock()
+	w.coord = nil
+	w.Unlock()
+}
+
+// Clean deletes the globally oldest archives until the budget is
+// met.  It is called after every rotation of the Writers.
+func (c *Coordinator) Clean() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	type owned struct {
+		ArchiveInfo
+		w *Writer
+	}
+	var all []owned
+	var total int64
+	for _, w := range c.writers {
+		if w.layout().hold {
+			continue
+		}
+		archives, err := w.archives()
+		if err != nil {
+			return fmt.Errorf("rotate: list archives: %w", err)
+		}
+		for _, a := range archives {
+			if w.isExempt(a) {
+				continue
+			}
+			all = append(all, owned{a, w})
+			total += a.Size
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].ModTime.Before(all[j].ModTime)
+	})
+
+	var dirs []string
+	changed := make(map[*Writer]bool)
+	for len(all) > 0 {
+		if (c.maxBytes <= 0 || total <= c.maxBytes) && (c.maxFiles <= 0 || len(all) <= c.maxFiles) {
+			break
+		}
+		a := all[0]
+		if err := a.w.removeArchive(a.ArchiveInfo); err != nil {
+			return fmt.Errorf("rotate: delete archive: %w", err)
+		}
+		changed[a.w] = true
+		if a.w.layout().syncDirs {
+			dirs = append(dirs, path.Dir(a.Path))
+		}
+		total -= a.Size
+		all = all[1:]
+	}
+	if err := syncDirs(dirs...); err != nil {
+		return fmt.Errorf("rotate: sync directories: %w", err)
+	}
+	for w := range changed {
+		if err := w.writeManifest(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
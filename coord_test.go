@@ -0,0 +1,111 @@
+package rotate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoordinator(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	c := NewCoordinator(0, 3)
+	start := time.Now().Add(-time.Hour)
+	for i, prefix := range []string{"a", "b"} {
+		x, err := New(path.Join(root, prefix), prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		x.SetMax(1)
+		for j := 0; j < 2; j++ {
+			if _, err := x.Write([]byte("hello\n")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// interleave the archives: a_1, b_1, a_2, b_2
+		for j := 0; j < 2; j++ {
+			mt := start.Add(time.Duration(2*j+i) * time.Minute)
+			p := path.Join(root, prefix, fmt.Sprintf("%s_%d", prefix, j+1))
+			if err := os.Chtimes(p, mt, mt); err != nil {
+				t.Fatal(err)
+			}
+		}
+		c.Add(x)
+	}
+	if err := c.Clean(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"a/a_1", "b/b_1", "a/a_2", "b/b_2"} {
+		_, err := os.Stat(path.Join(root, p))
+		if p == "a/a_1" {
+			if !os.IsNotExist(err) {
+				t.Errorf("%s: not deleted", p)
+			}
+		} else if err != nil {
+			t.Errorf("%s: %v", p, err)
+		}
+	}
+}
+
+func TestCoordinatorMaxConcurrent(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	c := NewCoordinator(0, 0)
+	c.SetMaxConcurrent(1)
+	var rotating, most int32
+	var ws []*Writer
+	for i := 0; i < 4; i++ {
+		prefix := fmt.Sprintf("w%d", i)
+		w, err := New(path.Join(root, prefix), prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer w.Close()
+		c.Add(w)
+		// called as the new current file is opened by a rotation
+		w.SetOnOpen(func(*os.File) error {
+			n := atomic.AddInt32(&rotating, 1)
+			for {
+				m := atomic.LoadInt32(&most)
+				if n <= m || atomic.CompareAndSwapInt32(&most, m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&rotating, -1)
+			return nil
+		})
+		w.Write([]byte("hello\n"))
+		ws = append(ws, w)
+	}
+	atomic.StoreInt32(&most, 0)
+
+	var wg sync.WaitGroup
+	for _, w := range ws {
+		wg.Add(1)
+		go func(w *Writer) {
+			defer wg.Done()
+			if err := w.Rotate(); err != nil {
+				t.Error(err)
+			}
+		}(w)
+	}
+	wg.Wait()
+	if most != 1 {
+		t.Errorf("%d Writers rotated at once", most)
+	}
+}
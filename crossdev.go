@@ -0,0 +1,15 @@
+//go:build !plan9
+
+package rotate
+
+import (
+	"os"
+	"syscall"
+)
+
+// isCrossDevice reports whether err is from a rename across
+// filesystems.
+func isCrossDevice(err error) bool {
+	le, ok := err.(*os.LinkError)
+	return ok && le.Err == syscall.EXDEV
+}
@@ -0,0 +1,7 @@
+package rotate
+
+// isCrossDevice reports whether err is from a rename across
+// filesystems, which Plan 9 does not tell apart.
+func isCrossDevice(err error) bool {
+	return false
+}
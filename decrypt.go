@@ -0,0 +1,60 @@
+package rotate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNoKeyProvider is returned when reading an encrypted archive
+// without a KeyProvider.
+var ErrNoKeyProvider = errors.New("rotate: encrypted archive and no key provider")
+
+// WithKeyProvider makes the Writer decrypt archives encrypted with
+// keys from kp when it reads them back, in OpenArchive, Search,
+// ArchivesBetween and the like.  A Writer created with Encrypted
+// live compression already uses its key provider.
+func WithKeyProvider(kp KeyProvider) Option {
+	return func(r *Writer) {
+		r.keys = kp
+	}
+}
+
+// keyProvider returns the KeyProvider to read archives with, or nil.
+// It does not need the lock.
+func (r *Writer) keyProvider() KeyProvider {
+	return r.keys
+}
+
+// OpenArchive opens the archive a for reading, transparently
+// decompressing and decrypting it, as for the other files the Writer
+// reads back.
+func (r *Writer) OpenArchive(a ArchiveInfo) (io.ReadCloser, error) {
+	rc, err := openDecompressed(a.Path, r.keyProvider())
+	if err != nil {
+		return nil, fmt.Errorf("rotate: open archive: %w", err)
+	}
+	return rc, nil
+}
+
+// OpenArchive opens the log file at p for reading, transparently
+// decompressing it if its extension is that of a known Compressor,
+// and decrypting it with keys from kp if it was encrypted by an
+// Encrypted Compressor.  kp may be nil if p is not encrypted.
+func OpenArchive(p string, kp KeyProvider) (io.ReadCloser, error) {
+	rc, err := openDecompressed(p, kp)
+	if err != nil {
+		return nil, fmt.Errorf("rotate: open archive: %w", err)
+	}
+	return rc, nil
+}
+
+// decrypterFor returns the Compressor to read the encrypted file p
+// with, given kp.
+func decrypterFor(p string, kp KeyProvider) (Compressor, error) {
+	if kp == nil {
+		return nil, ErrNoKeyProvider
+	}
+	return Encrypted(kp, compressorFor(strings.TrimSuffix(p, encExt))), nil
+}
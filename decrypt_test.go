@@ -0,0 +1,83 @@
+package rotate
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestOpenEncryptedArchive(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	kr := testKeyRing()
+	x, err := New(root, "mt", WithLiveCompression(Encrypted(kr, Gzip)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(6)
+	for _, s := range []string{"hello\n", "world\n"} {
+		if _, err := x.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// archives from before a key rotation are still readable
+	kr.Current = "k2"
+	if _, err := x.Write([]byte("again\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 3 {
+		t.Fatalf("archives: %+v", archives)
+	}
+	rc, err := x.OpenArchive(archives[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(data) != "world\n" {
+		t.Errorf("read %q: %v", data, err)
+	}
+
+	if _, err := OpenArchive(archives[0].Path, nil); !errors.Is(err, ErrNoKeyProvider) {
+		t.Errorf("no key provider: %v", err)
+	}
+	rc, err = OpenArchive(archives[0].Path, kr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = ioutil.ReadAll(rc)
+	rc.Close()
+	if string(data) != "hello\n" {
+		t.Errorf("read %q", data)
+	}
+
+	matches, err := x.Search(context.Background(), "^wor", SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].File != archives[1].Name {
+		t.Errorf("search: %+v", matches)
+	}
+
+	out, err := Tail(root, "mt", 3, FollowOptions{Keys: kr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "hello\nworld\nagain\n" {
+		t.Errorf("tail: %q", out)
+	}
+}
@@ -0,0 +1,54 @@
+package rotate
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// WithDiscard makes a Writer that keeps all its bookkeeping, the
+// size, the counter and rotations, but throws the data away and
+// touches neither the root nor any file in it, so tests and load
+// tests can run the logging paths without disk I/O.
+func WithDiscard() Option {
+	return func(r *Writer) {
+		r.discard = true
+	}
+}
+
+// openDiscard stands in for the current file of a Writer made with
+// WithDiscard.  The null device is opened so that the Writer does not
+// look closed and Control has a file to hand over.  The lock must be
+// held, unless the Writer is still being created.
+func (r *Writer) openDiscard() error {
+	f, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("rotate: open %s: %w", os.DevNull, err)
+	}
+	r.current = f
+	r.out = io.Discard
+	r.opened = time.Now()
+	if r.trigger != nil {
+		r.resetTrigger()
+	}
+	r.curPath.Store(os.DevNull)
+	return nil
+}
+
+// rotateDiscard does the bookkeeping of a rotation for a Writer made
+// with WithDiscard.  The lock must be held.
+func (r *Writer) rotateDiscard() {
+	now := time.Now()
+	r.counter++
+	r.size = 0
+	r.softFired = false
+	r.rotatePending = false
+	r.opened = now
+	r.lastRotation = now
+	atomic.AddUint64(&r.stats.rotations, 1)
+	if r.trigger != nil {
+		r.resetTrigger()
+	}
+}
@@ -0,0 +1,47 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestDiscard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	root := path.Join(dir, "logs")
+	x, err := New(root, "mt", WithDiscard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(10)
+	for i := 0; i < 5; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c := x.GetCounter(); c != 3 {
+		t.Errorf("counter: %d, expected 3", c)
+	}
+	h := x.Health()
+	if h.Size != 6 || h.LastRotation.IsZero() {
+		t.Errorf("health: %+v", h)
+	}
+	if err := x.PingWrite(); err != nil {
+		t.Errorf("ping: %v", err)
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Ping(); err != ErrClosed {
+		t.Errorf("ping after close: %v", err)
+	}
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Errorf("root created: %v", err)
+	}
+}
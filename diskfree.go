@@ -0,0 +1,87 @@
+package rotate
+
+import (
+	"fmt"
+	"os"
+)
+
+// diskFree returns the free bytes and inodes of the filesystem that
+// holds dir, or -1 for what the platform cannot tell.  It is a
+// variable so tests can fake a full disk.
+var diskFree = statDisk
+
+// SetMinFree makes the Writer delete its oldest archives before each
+// rotation, beyond its keep limit, while the filesystem that holds
+// the archives, the root's unless SetArchiveDir moved them, has
+// fewer than bytes free bytes or inodes free inodes.  Small
+// embedded partitions often run out of inodes long before bytes.  A
+// limit of zero is not enforced; both are by default.
+func (r *Writer) SetMinFree(bytes, inodes int64) {
+	r.Lock()
+	r.minFreeBytes = bytes
+	r.minFreeInodes = inodes
+	r.Unlock()
+}
+
+// archiveFree returns the free bytes and inodes of the filesystem
+// that holds the archives, which is the one deleting them frees
+// space on.  Until the archive directory is created, that of the
+// root is returned.
+func (r *Writer) archiveFree() (bytes, inodes int64, err error) {
+	bytes, inodes, err = diskFree(r.archiveRoot())
+	if os.IsNotExist(err) {
+		return diskFree(r.layout().root)
+	}
+	return bytes, inodes, err
+}
+
+// lowOnSpace reports whether the archives' filesystem is below the
+// minimum free space.
+func (r *Writer) lowOnSpace() (bool, error) {
+	bytes, inodes, err := r.archiveFree()
+	if err != nil {
+		return false, err
+	}
+	if r.minFreeBytes > 0 && bytes >= 0 && bytes < r.minFreeBytes {
+		return true, nil
+	}
+	if r.minFreeInodes > 0 && inodes >= 0 && inodes < r.minFreeInodes {
+		return true, nil
+	}
+	return false, nil
+}
+
+// ensureFree deletes the oldest archives until the minimum free
+// space is met or there are none left.  The lock must be held.
+func (r *Writer) ensureFree() error {
+	if r.minFreeBytes <= 0 && r.minFreeInodes <= 0 {
+		return nil
+	}
+	if r.layout().hold {
+		return r.warnLowSpace()
+	}
+	for {
+		low, err := r.lowOnSpace()
+		if err != nil {
+			return fmt.Errorf("rotate: check free space: %w", err)
+		}
+		if !low {
+			return nil
+		}
+		archives, err := r.archives()
+		if err != nil {
+			return fmt.Errorf("rotate: list archives: %w", err)
+		}
+		for len(archives) > 0 && r.isExempt(archives[0]) {
+			archives = archives[1:]
+		}
+		if len(archives) == 0 {
+			r.logf("low on space with no archives left to delete")
+			return nil
+		}
+		r.logf("low on space, deleting %s", archives[0].Name)
+		if err := r.removeArchive(archives[0]); err != nil {
+			return fmt.Errorf("rotate: delete archive: %w", err)
+		}
+	}
+}
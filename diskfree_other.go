@@ -0,0 +1,7 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package rotate
+
+func statDisk(dir string) (bytes, inodes int64, err error) {
+	return -1, -1, nil
+}
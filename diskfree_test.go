@@ -0,0 +1,81 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestMinFree(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	// every archive takes an inode from a partition with 3 spare
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { diskFree = statDisk }()
+	diskFree = func(dir string) (int64, int64, error) {
+		archives, err := x.archives()
+		if err != nil {
+			return 0, 0, err
+		}
+		return 1 << 30, int64(3 - len(archives)), nil
+	}
+	x.SetMax(1)
+	x.SetMinFree(0, 2)
+	for i := 0; i < 5; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 2 || archives[0].Name != "mt_4" {
+		t.Errorf("archives: %+v, expected mt_4 and mt_5", archives)
+	}
+	x.Close()
+}
+
+func TestMinFreeArchiveDir(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	// the root is full, but deleting archives could not help it
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	defer func() { diskFree = statDisk }()
+	diskFree = func(dir string) (int64, int64, error) {
+		if dir == root {
+			return 0, 0, nil
+		}
+		return 1 << 30, 1 << 20, nil
+	}
+	x.SetArchiveDir(path.Join(root, "old"))
+	x.SetMax(1)
+	x.SetMinFree(1<<20, 0)
+	for i := 0; i < 3; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if archives, _ := x.Archives(); len(archives) != 3 {
+		t.Errorf("archives: %+v", archives)
+	}
+	if u, err := x.DiskUsage(); err != nil || u.Free != 1<<30 {
+		t.Errorf("usage: %+v, %v", u, err)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build linux || darwin || freebsd
+
+package rotate
+
+import "syscall"
+
+func statDisk(dir string) (bytes, inodes int64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, 0, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), int64(st.Ffree), nil
+}
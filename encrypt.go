@@ -0,0 +1,392 @@
+package rotate
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A KeyProvider supplies the keys archives are encrypted with at
+// rest.  Keys are AES keys, 16, 24 or 32 bytes long, named by an ID
+// that is stored with the data, so that once the current key is
+// rotated older archives can still be decrypted with the key they
+// were written with.
+type KeyProvider interface {
+	// CurrentKey returns the key new data is encrypted with, and
+	// its ID, which must be at most 255 bytes long.
+	CurrentKey() (id string, key []byte, err error)
+
+	// Key returns the key with the ID id.
+	Key(id string) ([]byte, error)
+}
+
+// KeyRing is a KeyProvider holding its keys in memory, for keys
+// loaded from a secret store at start up.
+type KeyRing struct {
+	// Current is the ID of the key new data is encrypted with.
+	Current string
+
+	// Keys are the keys by ID, including older ones still needed
+	// to decrypt existing archives.
+	Keys map[string][]byte
+}
+
+// ErrUnknownKey is returned when a KeyRing has no key with an ID.
+var ErrUnknownKey = errors.New("rotate: unknown key")
+
+// CurrentKey returns the key named by Current and its ID.
+func (k *KeyRing) CurrentKey() (string, []byte, error) {
+	key, err := k.Key(k.Current)
+	return k.Current, key, err
+}
+
+// Key returns the key with the ID id, or an error wrapping
+// ErrUnknownKey if there is none.
+func (k *KeyRing) Key(id string) ([]byte, error) {
+	key, ok := k.Keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+	return key, nil
+}
+
+// Encrypted returns a Compressor that compresses with c, if not nil,
+// and encrypts the result with AES-GCM using the current key of kp.
+// Its extension is c's followed by .enc, as in .gz.enc.  Used with
+// WithLiveCompression nothing is stored in the clear; used with a
+// CompressionPolicy archives are encrypted some time after rotation.
+// The key IDs used are recorded in the archives' sidecar metadata.
+//
+// Reading an archive back needs a key provider that has the key it
+// was encrypted with.
+func Encrypted(kp KeyProvider, c Compressor) Compressor {
+	return &encryptedCompressor{kp: kp, c: c}
+}
+
+type encryptedCompressor struct {
+	kp KeyProvider
+	c  Compressor
+}
+
+func (e *encryptedCompressor) Ext() string {
+	if e.c == nil {
+		return encExt
+	}
+	return e.c.Ext() + encExt
+}
+
+func (e *encryptedCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	sw, err := newSealWriter(w, e.kp)
+	if err != nil {
+		return nil, err
+	}
+	if e.c == nil {
+		return sw, nil
+	}
+	cw, err := e.c.NewWriter(sw)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedWriter{WriteCloser: cw, seal: sw}, nil
+}
+
+func (e *encryptedCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	sr := &sealReader{r: r, kp: e.kp}
+	if e.c == nil {
+		return sr, nil
+	}
+	cr, err := e.c.NewReader(sr)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedReader{ReadCloser: cr, seal: sr}, nil
+}
+
+// encryptedWriter compresses into a sealWriter.
+type encryptedWriter struct {
+	io.WriteCloser
+	seal *sealWriter
+}
+
+func (e *encryptedWriter) Close() error {
+	err := e.WriteCloser.Close()
+	if serr := e.seal.Close(); err == nil {
+		err = serr
+	}
+	return err
+}
+
+// encryptedReader decompresses from a sealReader.
+type encryptedReader struct {
+	io.ReadCloser
+	seal *sealReader
+}
+
+// keyIDs returns the IDs of the keys used by w, a writer, or r, a
+// reader, of an encrypting Compressor, or nil.
+func keyIDs(v interface{}) []string {
+	switch v := v.(type) {
+	case *sealWriter:
+		return []string{v.id}
+	case *encryptedWriter:
+		return []string{v.seal.id}
+	case *sealReader:
+		return v.ids
+	case *encryptedReader:
+		return v.seal.ids
+	}
+	return nil
+}
+
+// An encrypted stream is a header, sealMagic followed by the length
+// of the key ID, the key ID and the nonce, followed by chunks of at
+// most sealChunk bytes of data.  Each chunk is its sealed length, as
+// 4 bytes big endian with the top bit set for the last chunk, and
+// the data sealed with the nonce XORed with the chunk's number.  The
+// last chunk, which may be empty, marks the end of the stream, so a
+// truncated stream is detected.  Streams may be concatenated, as
+// when a Writer appends to a current file after a restart.
+const (
+	encExt    = ".enc"
+	sealMagic = "RTENC1"
+	sealChunk = 65536
+	sealLast  = 1 << 31
+)
+
+// isEncrypted returns whether name is that of an encrypted file.
+func isEncrypted(name string) bool {
+	return strings.HasSuffix(name, encExt)
+}
+
+var errSealCorrupt = errors.New("rotate: corrupt encrypted data")
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce returns the nonce of chunk n of a stream with nonce base.
+func chunkNonce(dst, base []byte, n uint64) []byte {
+	dst = append(dst[:0], base...)
+	i := len(dst) - 8
+	binary.BigEndian.PutUint64(dst[i:], binary.BigEndian.Uint64(dst[i:])^n)
+	return dst
+}
+
+// sealWriter encrypts to w a chunk at a time.
+type sealWriter struct {
+	w     io.Writer
+	id    string
+	aead  cipher.AEAD
+	base  []byte
+	nonce []byte
+	n     uint64
+	buf   []byte
+	out   []byte
+	err   error
+}
+
+func newSealWriter(w io.Writer, kp KeyProvider) (*sealWriter, error) {
+	id, key, err := kp.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+	if len(id) > 255 {
+		return nil, fmt.Errorf("rotate: key ID %q too long", id)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	s := &sealWriter{w: w, id: id, aead: aead, buf: make([]byte, 0, sealChunk)}
+	s.base = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(s.base); err != nil {
+		return nil, err
+	}
+	s.out = append(s.out, sealMagic...)
+	s.out = append(s.out, byte(len(id)))
+	s.out = append(s.out, id...)
+	s.out = append(s.out, s.base...)
+	return s, nil
+}
+
+func (s *sealWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		if s.err != nil {
+			return n, s.err
+		}
+		m := copy(s.buf[len(s.buf):cap(s.buf)], p)
+		s.buf = s.buf[:len(s.buf)+m]
+		p = p[m:]
+		n += m
+		if len(s.buf) == cap(s.buf) {
+			s.err = s.flush(false)
+		}
+	}
+	return n, s.err
+}
+
+// flush writes the buffered data as a chunk, preceded by the header
+// if it has not been written yet.
+func (s *sealWriter) flush(last bool) error {
+	hdr := len(s.out)
+	s.out = append(s.out, 0, 0, 0, 0)
+	s.nonce = chunkNonce(s.nonce, s.base, s.n)
+	s.n++
+	s.out = s.aead.Seal(s.out, s.nonce, s.buf, sealAD(last))
+	n := uint32(len(s.out) - hdr - 4)
+	if last {
+		n |= sealLast
+	}
+	binary.BigEndian.PutUint32(s.out[hdr:], n)
+	s.buf = s.buf[:0]
+	_, err := s.w.Write(s.out)
+	s.out = s.out[:0]
+	return err
+}
+
+// sealAD returns the additional data of a chunk, which binds whether
+// it is the last.
+func sealAD(last bool) []byte {
+	if last {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// Close writes the last chunk, but does not close the underlying
+// writer.
+func (s *sealWriter) Close() error {
+	if s.err != nil {
+		return s.err
+	}
+	s.err = s.flush(true)
+	if s.err == nil {
+		s.err = errors.New("rotate: encrypting writer closed")
+		return nil
+	}
+	return s.err
+}
+
+// sealReader decrypts a stream, or several concatenated.
+type sealReader struct {
+	r     io.Reader
+	kp    KeyProvider
+	ids   []string // of the keys of the streams read so far
+	aead  cipher.AEAD
+	base  []byte
+	nonce []byte
+	n     uint64
+	in    []byte
+	plain []byte
+	out   []byte
+	err   error
+}
+
+func (s *sealReader) Read(p []byte) (int, error) {
+	for len(s.out) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		s.err = s.next()
+	}
+	n := copy(p, s.out)
+	s.out = s.out[n:]
+	return n, nil
+}
+
+// next decrypts the next chunk, reading the header of a stream first
+// if one is starting.
+func (s *sealReader) next() error {
+	if s.aead == nil {
+		if err := s.header(); err != nil {
+			return err
+		}
+	}
+	var hdr [4]byte
+	if _, err := io.ReadFull(s.r, hdr[:]); err != nil {
+		return unexpected(err)
+	}
+	n := binary.BigEndian.Uint32(hdr[:])
+	last := n&sealLast != 0
+	n &^= sealLast
+	if n > sealChunk+uint32(s.aead.Overhead()) {
+		return errSealCorrupt
+	}
+	if cap(s.in) < int(n) {
+		s.in = make([]byte, n)
+	}
+	s.in = s.in[:n]
+	if _, err := io.ReadFull(s.r, s.in); err != nil {
+		return unexpected(err)
+	}
+	s.nonce = chunkNonce(s.nonce, s.base, s.n)
+	s.n++
+	plain, err := s.aead.Open(s.plain[:0], s.nonce, s.in, sealAD(last))
+	if err != nil {
+		return errSealCorrupt
+	}
+	s.plain = plain
+	s.out = plain
+	if last {
+		s.aead = nil
+	}
+	return nil
+}
+
+// header reads the header of a stream and looks up its key.  It
+// returns io.EOF if there are no more streams.
+func (s *sealReader) header() error {
+	var magic [len(sealMagic) + 1]byte
+	if _, err := io.ReadFull(s.r, magic[:]); err != nil {
+		if err == io.EOF {
+			return io.EOF
+		}
+		return unexpected(err)
+	}
+	if string(magic[:len(sealMagic)]) != sealMagic {
+		return errSealCorrupt
+	}
+	id := make([]byte, magic[len(sealMagic)])
+	if _, err := io.ReadFull(s.r, id); err != nil {
+		return unexpected(err)
+	}
+	key, err := s.kp.Key(string(id))
+	if err != nil {
+		return err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	s.base = make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(s.r, s.base); err != nil {
+		return unexpected(err)
+	}
+	s.aead = aead
+	s.n = 0
+	if k := len(s.ids); k == 0 || s.ids[k-1] != string(id) {
+		s.ids = append(s.ids, string(id))
+	}
+	return nil
+}
+
+func (s *sealReader) Close() error { return nil }
+
+// unexpected turns io.EOF, found in the middle of a stream, into
+// io.ErrUnexpectedEOF.
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
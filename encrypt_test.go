@@ -0,0 +1,138 @@
+package rotate
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testKeyRing() *KeyRing {
+	return &KeyRing{
+		Current: "k1",
+		Keys: map[string][]byte{
+			"k1": bytes.Repeat([]byte{1}, 32),
+			"k2": bytes.Repeat([]byte{2}, 16),
+		},
+	}
+}
+
+func TestEncrypted(t *testing.T) {
+	kr := testKeyRing()
+	data := []byte(strings.Repeat("a secret line of log\n", 10000))
+	for _, c := range []Compressor{Encrypted(kr, nil), Encrypted(kr, Gzip)} {
+		var buf bytes.Buffer
+		w, err := c.NewWriter(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		first := buf.Len()
+		// a second stream, after the key was rotated
+		kr.Current = "k2"
+		w, _ = c.NewWriter(&buf)
+		w.Write([]byte("more\n"))
+		w.Close()
+		kr.Current = "k1"
+
+		if bytes.Contains(buf.Bytes(), []byte("secret")) {
+			t.Fatalf("%s: not encrypted", c.Ext())
+		}
+		rc, err := c.NewReader(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("%s: %v", c.Ext(), err)
+		}
+		if !bytes.Equal(got, append(data, "more\n"...)) {
+			t.Errorf("%s: read %d bytes", c.Ext(), len(got))
+		}
+		if ids := keyIDs(rc); !reflect.DeepEqual(ids, []string{"k1", "k2"}) {
+			t.Errorf("%s: key IDs %q", c.Ext(), ids)
+		}
+
+		// truncated at a chunk boundary
+		if err := readAll(c, buf.Bytes()[:first-20]); err == nil {
+			t.Errorf("%s: truncation not detected", c.Ext())
+		}
+		// tampered with
+		b := append([]byte(nil), buf.Bytes()...)
+		b[100] ^= 1
+		if err := readAll(c, b); err == nil {
+			t.Errorf("%s: modification not detected", c.Ext())
+		}
+	}
+
+	if err := readAll(Encrypted(&KeyRing{Keys: map[string][]byte{}}, nil), mustSeal(t, kr, "x")); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("missing key: %v", err)
+	}
+}
+
+// readAll returns the error reading b with c.
+func readAll(c Compressor, b []byte) error {
+	rc, err := c.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = ioutil.ReadAll(rc)
+	return err
+}
+
+func mustSeal(t *testing.T, kp KeyProvider, s string) []byte {
+	var buf bytes.Buffer
+	w, err := Encrypted(kp, nil).NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(w, s)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestEncryptedArchives(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	kr := testKeyRing()
+	x, err := New(root, "mt", WithLiveCompression(Encrypted(kr, Gzip)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	x.SetSidecar(true)
+	x.SetMax(6)
+	// the second archive is started after the key is rotated
+	for _, s := range []string{"hel", "lo\n", "hello\n"} {
+		if _, err := x.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+		kr.Current = "k2"
+	}
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 2 || archives[0].Name != "mt_1.gz.enc" {
+		t.Fatalf("archives: %+v", archives)
+	}
+	for i, a := range archives {
+		if a.Meta == nil || a.Meta.Lines != 1 || !reflect.DeepEqual(a.Meta.KeyIDs, []string{"k1", "k2"}[i:i+1]) {
+			t.Errorf("%s: metadata %+v", a.Name, a.Meta)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package rotate
+
+import "errors"
+
+// Errors returned by a Writer.  They may be wrapped with more
+// context, so test for them with errors.Is.
+var (
+	// ErrClosed is returned when a Writer is used after Close.
+	ErrClosed = errors.New("rotate: writer is closed")
+
+	// ErrRootNotDir is returned by New when root exists but is not
+	// a directory.
+	ErrRootNotDir = errors.New("rotate: root is not a directory")
+
+	// ErrNameCollision is returned when a rotation would replace
+	// an existing file.  The current file is kept.
+	ErrNameCollision = errors.New("rotate: archive name already exists")
+
+	// ErrQueueFull is returned by an asynchronous Writer that
+	// drops writes when its queue is full.
+	ErrQueueFull = errors.New("rotate: write queue is full")
+
+	// ErrInvalidName is returned when a prefix or file name
+	// contains a path separator or is "..", which would place
+	// files outside the root.
+	ErrInvalidName = errors.New("rotate: invalid name")
+)
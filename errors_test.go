@@ -0,0 +1,82 @@
+package rotate
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	file := path.Join(root, "file")
+	if err := ioutil.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(file, "mt"); !errors.Is(err, ErrRootNotDir) {
+		t.Errorf("New on a file: %v, expected ErrRootNotDir", err)
+	}
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(root, "mt_1"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	x.SetCounter(1)
+	x.SetMax(1)
+	if _, err := x.Write([]byte("hello\n")); !errors.Is(err, ErrNameCollision) {
+		t.Errorf("rotating onto mt_1: %v, expected ErrNameCollision", err)
+	}
+
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.Write([]byte("hello\n")); !errors.Is(err, ErrClosed) {
+		t.Errorf("Write after Close: %v, expected ErrClosed", err)
+	}
+	if err := x.Close(); !errors.Is(err, ErrClosed) {
+		t.Errorf("second Close: %v, expected ErrClosed", err)
+	}
+}
+
+func TestInvalidName(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for _, prefix := range []string{"../mt", "logs/mt", ".."} {
+		if _, err := New(root, prefix); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("prefix %q: %v, expected ErrInvalidName", prefix, err)
+		}
+	}
+	if _, err := New(root, "mt", WithFileName("../escape.log")); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("file name: %v, expected ErrInvalidName", err)
+	}
+
+	x, err := New(root, "mt", WithFileName("app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	x.SetFileName("/etc/passwd")
+	if err := x.LastError(); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("SetFileName: %v, expected ErrInvalidName", err)
+	}
+	x.SetMax(1)
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path.Join(root, "app.log")); err != nil {
+		t.Errorf("current file after an invalid SetFileName: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package rotate
+
+import (
+	"sync"
+	"time"
+)
+
+// eventBuffer is the number of events a subscriber can fall behind
+// by before events are dropped for it.
+const eventBuffer = 64
+
+// EventKind is the type of an Event.
+type EventKind int
+
+const (
+	// EventRotated is sent when the current file has been
+	// archived.  Archive is the new archive.
+	EventRotated EventKind = iota + 1
+
+	// EventDeleted is sent when an archive has been deleted.
+	EventDeleted
+
+	// EventCompressed is sent when an archive has been
+	// compressed.  Archive is the compressed archive.
+	EventCompressed
+
+	// EventError is sent for errors recorded for Health.
+	EventError
+
+	// EventFailover is sent when the Writer switches to its
+	// fallback root, with Err set to the cause, and when it
+	// switches back.  Root is the root it switched to.
+	EventFailover
+
+	// EventLowSpace is sent when a Writer under hold is low on
+	// space but may not delete archives.  Root is the root.
+	EventLowSpace
+)
+
+var eventNames = map[EventKind]string{
+	EventRotated:    "rotated",
+	EventDeleted:    "deleted",
+	EventCompressed: "compressed",
+	EventError:      "error",
+	EventFailover:   "failover",
+	EventLowSpace:   "low_space",
+}
+
+func (k EventKind) String() string {
+	if s, ok := eventNames[k]; ok {
+		return s
+	}
+	return "unknown"
+}
+
+// An Event is something that happened to a Writer's files.
+type Event struct {
+	Kind    EventKind
+	Time    time.Time
+	Archive ArchiveInfo
+	Root    string
+	Err     error
+
+	// Reason is why the file was rotated, for EventRotated.
+	Reason RotationReason
+}
+
+// events holds the subscribers of a Writer.
+type events struct {
+	mu     sync.Mutex
+	subs   []chan Event
+	closed bool
+}
+
+// Events returns a new channel that receives the Writer's events,
+// so any number of parts of a program can react to rotations,
+// deletions and errors without being wired together.  Events are
+// never waited for: a subscriber that falls behind by more than 64
+// events misses the ones that follow.  The channel is closed when
+// the Writer is.
+func (r *Writer) Events() <-chan Event {
+	ch := make(chan Event, eventBuffer)
+	r.events.mu.Lock()
+	if r.events.closed {
+		close(ch)
+	} else {
+		r.events.subs = append(r.events.subs, ch)
+	}
+	r.events.mu.Unlock()
+	return ch
+}
+
+// emit sends e to the subscribers.  It does not need the lock.
+func (r *Writer) emit(e Event) {
+	r.events.mu.Lock()
+	defer r.events.mu.Unlock()
+	if r.events.closed || len(r.events.subs) == 0 {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	for _, ch := range r.events.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// closeEvents closes the subscribers' channels.
+func (r *Writer) closeEvents() {
+	r.events.mu.Lock()
+	defer r.events.mu.Unlock()
+	if r.events.closed {
+		return
+	}
+	r.events.closed = true
+	for _, ch := range r.events.subs {
+		close(ch)
+	}
+	r.events.subs = nil
+}
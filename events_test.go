@@ -0,0 +1,42 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestEvents(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := x.Events(), x.Events()
+	x.SetMax(1)
+	x.SetKeep(1)
+	for i := 0; i < 2; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	x.Close()
+
+	for _, ch := range []<-chan Event{a, b} {
+		var kinds []EventKind
+		for e := range ch {
+			kinds = append(kinds, e.Kind)
+		}
+		if len(kinds) != 3 || kinds[0] != EventRotated || kinds[1] != EventRotated || kinds[2] != EventDeleted {
+			t.Errorf("events: %v, expected two rotations and a deletion", kinds)
+		}
+	}
+	if _, ok := <-x.Events(); ok {
+		t.Error("subscribing after close got an open channel")
+	}
+}
@@ -0,0 +1,42 @@
+package rotate
+
+import (
+	"fmt"
+	"path"
+)
+
+// SetRetentionExempt exempts archives whose name, as in
+// ArchiveInfo.Name, or base name matches one of the glob patterns,
+// in the syntax of path.Match, from retention, as Pin does.  This
+// keeps files operators saved in the root under an archive's name,
+// such as "mt_17" or "2024/05/*" with SetDatedDirs, from being swept
+// away.  Files that are not named like archives are never deleted.
+// No patterns, the default, exempt nothing.
+func (r *Writer) SetRetentionExempt(patterns ...string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("rotate: retention exemption %q: %w", p, err)
+		}
+	}
+	r.Lock()
+	defer r.Unlock()
+	lay := *r.layout()
+	lay.exempt = append([]string(nil), patterns...)
+	r.lay.Store(&lay)
+	return nil
+}
+
+// isExempt returns whether retention must not delete the archive a,
+// because it is pinned or matches a pattern of SetRetentionExempt.
+// It does not need the lock.
+func (r *Writer) isExempt(a ArchiveInfo) bool {
+	for _, p := range r.layout().exempt {
+		if ok, _ := path.Match(p, a.Name); ok {
+			return true
+		}
+		if ok, _ := path.Match(p, path.Base(a.Name)); ok {
+			return true
+		}
+	}
+	return r.isPinned(a)
+}
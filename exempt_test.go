@@ -0,0 +1,46 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestRetentionExempt(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	if err := x.SetRetentionExempt("["); err == nil {
+		t.Error("bad pattern accepted")
+	}
+	if err := x.SetRetentionExempt("*_2"); err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(6)
+	x.SetKeep(1)
+	// a copy saved by an operator under an archive's name
+	if err := ioutil.WriteFile(root+"/mt_2", []byte("saved\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	x.SetCounter(3)
+	for i := 0; i < 3; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 2 || archives[0].Name != "mt_2" || archives[1].Name != "mt_5" {
+		t.Errorf("archives: %+v", archives)
+	}
+}
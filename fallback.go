@@ -0,0 +1,118 @@
+package rotate
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"time"
+)
+
+// retryDefault is how often a Writer on its fallback root tries to
+// switch back by default.
+const retryDefault = time.Minute
+
+// Fallback configures a secondary root for a Writer.  See
+// SetFallback.
+type Fallback struct {
+	// Root is the directory used while the primary root fails,
+	// such as /tmp/logs.  Empty turns the fallback off.
+	Root string
+
+	// Retry is how often a Writer on the fallback root tries to
+	// switch back to the primary, at its next write.  The default
+	// is a minute.
+	Retry time.Duration
+
+	// OnSwitch, if set, is called whenever the Writer switches
+	// roots, with the root it switched to and, when it failed over,
+	// the error that made it.  It is called with the lock held, so
+	// it must not use the Writer.
+	OnSwitch func(root string, err error)
+}
+
+// SetFallback sets a root that the Writer switches to when a write
+// to the current file fails, as when the primary root's filesystem
+// becomes read-only or the directory is removed.  The write is
+// completed in a new current file in the fallback root, and archives
+// rotated while there stay there, unless an archive directory is
+// set.  The failover is recorded for Health.
+func (r *Writer) SetFallback(f Fallback) {
+	if f.Retry <= 0 {
+		f.Retry = retryDefault
+	}
+	r.Lock()
+	r.fallback = f
+	r.Unlock()
+}
+
+// setRoot changes the root the Writer uses.  The lock must be held.
+func (r *Writer) setRoot(dir string) {
+	r.root = dir
+	lay := *r.layout()
+	lay.root = dir
+	r.lay.Store(&lay)
+}
+
+// failover switches to the fallback root after a write failed with
+// cause, and reports whether it did.  The lock must be held.
+func (r *Writer) failover(cause error) bool {
+	fb := r.fallback.Root
+	if fb == "" || r.primary != "" || fb == r.root {
+		return false
+	}
+	primary := r.root
+	r.closeCurrent()
+	if err := r.mkdirAll(fb); err == nil {
+		r.setRoot(fb)
+		if err := r.openCurrent(); err == nil {
+			r.primary = primary
+			r.nextTry = time.Now().Add(r.fallback.Retry)
+			err := fmt.Errorf("rotate: switched to fallback root %s: %w", fb, cause)
+			r.recordError(err)
+			r.emit(Event{Kind: EventFailover, Root: fb, Err: err})
+			if r.fallback.OnSwitch != nil {
+				r.fallback.OnSwitch(fb, err)
+			}
+			return true
+		}
+		r.setRoot(primary)
+	}
+	// keep trying the primary
+	if err := r.openCurrent(); err != nil {
+		r.recordError(fmt.Errorf("rotate: no current file open after failing over from %s: %w", primary, err))
+	}
+	return false
+}
+
+// switchBack returns to the primary root if it is time to try and
+// the current file there can be opened.  The lock must be held.
+func (r *Writer) switchBack() {
+	if r.primary == "" || time.Now().Before(r.nextTry) {
+		return
+	}
+	r.nextTry = time.Now().Add(r.fallback.Retry)
+	f, err := os.OpenFile(path.Join(r.primary, r.currentName()), os.O_WRONLY|os.O_CREATE|os.O_APPEND, r.filePerm)
+	if err != nil {
+		return
+	}
+	f.Close()
+
+	fb := r.root
+	if err := r.closeCurrent(); err != nil {
+		r.recordError(fmt.Errorf("rotate: close current file in fallback root: %w", err))
+	}
+	r.setRoot(r.primary)
+	if err := r.openCurrent(); err != nil {
+		r.setRoot(fb)
+		if err := r.openCurrent(); err != nil {
+			r.recordError(fmt.Errorf("rotate: no current file open after failing to switch back to %s: %w", r.primary, err))
+		}
+		return
+	}
+	r.logf("switched back to %s", r.root)
+	r.primary = ""
+	r.emit(Event{Kind: EventFailover, Root: r.root})
+	if r.fallback.OnSwitch != nil {
+		r.fallback.OnSwitch(r.root, nil)
+	}
+}
@@ -0,0 +1,91 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFallback(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	primary, fallback := path.Join(root, "primary"), path.Join(root, "fallback")
+
+	x, err := New(primary, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var switches []string
+	x.SetFallback(Fallback{
+		Root:     fallback,
+		Retry:    20 * time.Millisecond,
+		OnSwitch: func(root string, err error) { switches = append(switches, root) },
+	})
+	if _, err := x.Write([]byte("one\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// make writes to the primary fail
+	x.current.Close()
+	if _, err := x.Write([]byte("two\n")); err != nil {
+		t.Fatalf("write with a fallback: %v", err)
+	}
+	if x.LastError() == nil {
+		t.Error("failover not recorded")
+	}
+	if b, _ := ioutil.ReadFile(path.Join(fallback, "default.log")); string(b) != "two\n" {
+		t.Errorf("fallback: %q", b)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, err := x.Write([]byte("three\n")); err != nil {
+		t.Fatal(err)
+	}
+	x.Close()
+	if b, _ := ioutil.ReadFile(path.Join(primary, "default.log")); string(b) != "one\nthree\n" {
+		t.Errorf("primary: %q", b)
+	}
+	if len(switches) != 2 || switches[0] != fallback || switches[1] != primary {
+		t.Errorf("switches: %v", switches)
+	}
+}
+
+func TestFallbackUnavailable(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	primary, fallback := path.Join(root, "primary"), path.Join(root, "fallback")
+
+	x, err := New(primary, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	// neither root can take a current file
+	if err := ioutil.WriteFile(fallback, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	x.SetFallback(Fallback{Root: fallback})
+	cp := path.Join(primary, fileDefault)
+	if err := os.Remove(cp); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(cp, 0755); err != nil {
+		t.Fatal(err)
+	}
+	x.current.Close()
+	if _, err := x.Write([]byte("one\n")); err == nil {
+		t.Error("write succeeded")
+	}
+	if err := x.LastError(); err == nil || !strings.Contains(err.Error(), "no current file open") {
+		t.Errorf("last error: %v", err)
+	}
+}
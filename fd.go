@@ -0,0 +1,73 @@
+package rotate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ExportFile returns the current file, with everything buffered
+// written out, so a binary upgrade can pass it to the new process,
+// in exec.Cmd.ExtraFiles for instance, which then adopts it with
+// NewFromFD and carries on writing without closing and reopening the
+// file.  The file still belongs to the Writer, which must not be
+// written to after the new process has started; pair this with
+// Snapshot and Restore to hand over the rest of the Writer's state.
+// It fails with live compression, whose stream cannot be continued
+// by another process.
+func (r *Writer) ExportFile() (*os.File, error) {
+	r.Lock()
+	defer r.Unlock()
+	if err := r.ready(); err != nil {
+		return nil, err
+	}
+	if r.enc != nil {
+		return nil, errors.New("rotate: a compressed current file can not be handed over")
+	}
+	if err := r.flush(); err != nil {
+		return nil, err
+	}
+	return r.current, nil
+}
+
+// NewFromFD is like New, but adopts the open file descriptor fd,
+// inherited from the process that called ExportFile, as the current
+// file rather than opening it.  The descriptor must refer to the
+// current file New would open, given root and the options.
+func NewFromFD(root, prefix string, fd uintptr, opts ...Option) (*Writer, error) {
+	f := os.NewFile(fd, "inherited current file")
+	if f == nil {
+		return nil, fmt.Errorf("rotate: bad file descriptor %d", fd)
+	}
+	w, err := New(root, prefix, append(opts, func(r *Writer) {
+		r.inherited = f
+	})...)
+	if err != nil {
+		// the Writer may have closed it already
+		f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// openInherited makes the file given to NewFromFD the current file,
+// after checking that it is cp.  The lock must be held.
+func (r *Writer) openInherited(cp string) error {
+	f := r.inherited
+	r.inherited = nil
+	if r.live != nil {
+		f.Close()
+		return errors.New("rotate: a compressed current file can not be inherited")
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("rotate: stat inherited file: %w", err)
+	}
+	if cfi, err := os.Stat(cp); err != nil || !os.SameFile(fi, cfi) {
+		f.Close()
+		return fmt.Errorf("rotate: inherited file is not %s", cp)
+	}
+	r.logf("inherited %s", cp)
+	return r.useCurrent(f, cp)
+}
@@ -0,0 +1,68 @@
+//go:build unix
+
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"syscall"
+	"testing"
+)
+
+func TestFileHandoff(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	old, err := New(root, "mt", WithBuffer(4096))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := old.Write([]byte("before\n")); err != nil {
+		t.Fatal(err)
+	}
+	f, err := old.ExportFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// as the new process would inherit it
+	fd, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := NewFromFD(root, "mt", uintptr(fd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.Write([]byte("after\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+	old.Close()
+	b, err := ioutil.ReadFile(path.Join(root, fileDefault))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "before\nafter\n" {
+		t.Errorf("got %q", b)
+	}
+
+	// a descriptor for another file is refused
+	other, err := os.Create(path.Join(root, "other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	fd, err = syscall.Dup(int(other.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFromFD(root, "mt", uintptr(fd)); err == nil {
+		t.Error("NewFromFD with another file succeeded")
+	}
+}
@@ -0,0 +1,34 @@
+package rotate
+
+import "os"
+
+// Control calls fn with the current file while holding the lock, so
+// the file is neither rotated nor closed until fn returns.  It lets
+// fn apply platform specific settings, such as with fcntl or ioctl.
+// fn must not write to, close or keep the file.  Settings applied
+// this way are lost when the file is rotated; see SetOnOpen.  A
+// file put off by WithLazyOpen is opened first.
+func (r *Writer) Control(fn func(f *os.File) error) error {
+	r.Lock()
+	defer r.Unlock()
+	if err := r.ready(); err != nil {
+		return err
+	}
+	return fn(r.current)
+}
+
+// SetOnOpen sets a function that is called with every new current
+// file right after it is opened, and with the file already open, so
+// settings applied to the file last through rotation.  The same
+// rules as for Control apply to fn.  An error from fn when a new file
+// is opened does not stop the Writer; it is recorded for Health.
+// SetOnOpen returns fn's error for the file already open.
+func (r *Writer) SetOnOpen(fn func(f *os.File) error) error {
+	r.Lock()
+	defer r.Unlock()
+	r.onOpen = fn
+	if fn == nil || r.current == nil {
+		return nil
+	}
+	return fn(r.current)
+}
@@ -0,0 +1,52 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestControl(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var name string
+	if err := x.Control(func(f *os.File) error {
+		name = f.Name()
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if name != path.Join(root, fileDefault) {
+		t.Errorf("file: %s", name)
+	}
+
+	var opened int
+	if err := x.SetOnOpen(func(f *os.File) error {
+		opened++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(1)
+	for i := 0; i < 3; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if opened != 4 {
+		t.Errorf("opened: %d, expected 4", opened)
+	}
+	x.Close()
+	if err := x.Control(func(f *os.File) error { return nil }); err != ErrClosed {
+		t.Errorf("control after close: %v", err)
+	}
+}
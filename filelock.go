@@ -0,0 +1,49 @@
+package rotate
+
+import "os"
+
+// fileLock is an exclusive advisory lock on a file, shared with
+// other processes.  It is implemented with flock on Unix and
+// LockFileEx on Windows; on Solaris, AIX and other systems without
+// flock it is not supported.  Within the process it may be taken again
+// by the holder, which must release it as many times.  It is not
+// safe for concurrent use; the Writer's lock serializes its use.
+type fileLock struct {
+	f    *os.File
+	held int
+}
+
+// newFileLock opens the lock file at p, creating it if necessary.
+func newFileLock(p string, perm os.FileMode) (*fileLock, error) {
+	f, err := os.OpenFile(p, os.O_RDWR|os.O_CREATE, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &fileLock{f: f}, nil
+}
+
+// lock waits until the lock is held.
+func (l *fileLock) lock() error {
+	if l.held == 0 {
+		if err := lockFile(l.f); err != nil {
+			return err
+		}
+	}
+	l.held++
+	return nil
+}
+
+// unlock releases the lock.
+func (l *fileLock) unlock() error {
+	l.held--
+	if l.held == 0 {
+		return unlockFile(l.f)
+	}
+	return nil
+}
+
+// close closes the lock file, which releases the lock.
+func (l *fileLock) close() error {
+	l.held = 0
+	return l.f.Close()
+}
@@ -0,0 +1,308 @@
+package rotate
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"time"
+)
+
+// pollDefault is how often Follow looks for new data by default.
+const pollDefault = 250 * time.Millisecond
+
+// FollowOptions controls Tail and Follow.
+type FollowOptions struct {
+	// Options describe the logs as the Writer that writes them is
+	// configured: NamePattern, DatedDirs and ArchiveDir are used
+	// to find the archives.  An empty NamePattern is the default
+	// one.
+	Options Options
+
+	// FileName is the name of the current file.  The default is
+	// that of a Writer.
+	FileName string
+
+	// Keys decrypts encrypted archives.
+	Keys KeyProvider
+
+	// Poll is how often Follow checks for new data.  The default
+	// is a quarter of a second.
+	Poll time.Duration
+}
+
+// Tail returns the last n lines of the logs of prefix in root,
+// reaching back into the archives when the current file has fewer.
+func Tail(root, prefix string, n int, o FollowOptions) ([]byte, error) {
+	r, cp, err := openLogs(root, prefix, o)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(cp)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if f != nil {
+		defer f.Close()
+	}
+	data, _, err := r.lastLines(f, n)
+	return data, err
+}
+
+// Follow returns a reader of the logs of prefix in root that starts
+// with their last n lines and, like tail -F, waits at the end of the
+// current file for more to be written instead of returning io.EOF,
+// moving on to the new current file after every rotation.  Once ctx
+// is done, Read returns ctx.Err().  A current file compressed with
+// WithLiveCompression can not be followed.
+func Follow(ctx context.Context, root, prefix string, n int, o FollowOptions) (io.ReadCloser, error) {
+	r, cp, err := openLogs(root, prefix, o)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(cp)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	pre, off, err := r.lastLines(f, n)
+	if err != nil {
+		if f != nil {
+			f.Close()
+		}
+		return nil, err
+	}
+	if f != nil {
+		if _, err := f.Seek(off, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	poll := o.Poll
+	if poll <= 0 {
+		poll = pollDefault
+	}
+	return &follower{ctx: ctx, path: cp, poll: poll, f: f, pre: pre, done: make(chan struct{})}, nil
+}
+
+// openLogs returns a Writer, without a current file, that knows the
+// layout of the logs, and the path of the current file.
+func openLogs(root, prefix string, o FollowOptions) (*Writer, string, error) {
+	r, err := newWriter(root, prefix)
+	if err != nil {
+		return nil, "", err
+	}
+	if o.Options.NamePattern == "" {
+		o.Options.NamePattern = namePatternDefault
+	}
+	WithOptions(o.Options)(r)
+	r.keys = o.Keys
+	if r.optErr != nil {
+		return nil, "", r.optErr
+	}
+	name := o.FileName
+	if name == "" {
+		name = fileDefault
+	}
+	if err := checkFileName(name); err != nil {
+		return nil, "", err
+	}
+	return r, path.Join(root, name), nil
+}
+
+// lastLines returns the last n lines of the logs, ending with those
+// of the current file f, if not nil, as far as its size when it is
+// read, which is returned too.
+func (r *Writer) lastLines(f *os.File, n int) ([]byte, int64, error) {
+	var parts [][]byte
+	var size int64
+	if f != nil {
+		fi, err := f.Stat()
+		if err != nil {
+			return nil, 0, err
+		}
+		size = fi.Size()
+		start, found, err := tailLines(f, size, n)
+		if err != nil {
+			return nil, 0, err
+		}
+		b := make([]byte, size-start)
+		if _, err := f.ReadAt(b, start); err != nil && err != io.EOF {
+			return nil, 0, err
+		}
+		parts = append(parts, b)
+		n -= found
+	}
+	if n > 0 {
+		archives, err := r.archives()
+		if err != nil {
+			return nil, 0, err
+		}
+		for i := len(archives) - 1; i >= 0 && n > 0; i-- {
+			rc, err := openDecompressed(archives[i].Path, r.keyProvider())
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, 0, err
+			}
+			data, err := ioutil.ReadAll(rc)
+			rc.Close()
+			if err != nil && err != io.ErrUnexpectedEOF {
+				return nil, 0, err
+			}
+			start, found, _ := tailLines(bytes.NewReader(data), int64(len(data)), n)
+			parts = append(parts, data[start:])
+			n -= found
+		}
+	}
+	var b []byte
+	for i := len(parts) - 1; i >= 0; i-- {
+		b = append(b, parts[i]...)
+	}
+	return b, size, nil
+}
+
+// tailLines returns the offset in f, which is size bytes long, at
+// which its last n lines start, reading backwards from the end, and
+// the number of lines found, which is less than n if f is shorter.
+func tailLines(f io.ReaderAt, size int64, n int) (int64, int, error) {
+	if n <= 0 || size == 0 {
+		return size, 0, nil
+	}
+	buf := make([]byte, tailScan)
+	found := 0
+	for end := size; end > 0; {
+		off := end - int64(len(buf))
+		if off < 0 {
+			off = 0
+		}
+		b := buf[:end-off]
+		if _, err := f.ReadAt(b, off); err != nil && err != io.EOF {
+			return 0, 0, err
+		}
+		for i := len(b) - 1; i >= 0; i-- {
+			// a final newline ends the last line rather than
+			// starting another
+			if b[i] == '\n' && off+int64(i) != size-1 {
+				found++
+				if found == n {
+					return off + int64(i) + 1, n, nil
+				}
+			}
+		}
+		end = off
+	}
+	return 0, found + 1, nil
+}
+
+// follower is the reader returned by Follow.
+type follower struct {
+	ctx  context.Context
+	path string
+	poll time.Duration
+	pre  []byte
+	done chan struct{}
+
+	mu     sync.Mutex // held by Read except while it waits
+	f      *os.File
+	closed bool
+}
+
+func (fl *follower) Read(p []byte) (int, error) {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+	for {
+		if fl.closed {
+			return 0, ErrClosed
+		}
+		if len(fl.pre) > 0 {
+			n := copy(p, fl.pre)
+			fl.pre = fl.pre[n:]
+			return n, nil
+		}
+		if fl.f != nil {
+			n, err := fl.f.Read(p)
+			if n > 0 || (err != nil && err != io.EOF) {
+				return n, err
+			}
+		}
+		next, err := fl.rotated()
+		if err != nil {
+			return 0, err
+		}
+		if next != nil {
+			if fl.f != nil {
+				// the old file was complete when it was
+				// renamed, but may have grown since the last
+				// read
+				n, err := fl.f.Read(p)
+				if n > 0 || (err != nil && err != io.EOF) {
+					next.Close()
+					return n, err
+				}
+				fl.f.Close()
+			}
+			fl.f = next
+			continue
+		}
+
+		fl.mu.Unlock()
+		select {
+		case <-fl.ctx.Done():
+			fl.mu.Lock()
+			return 0, fl.ctx.Err()
+		case <-fl.done:
+		case <-time.After(fl.poll):
+		}
+		fl.mu.Lock()
+	}
+}
+
+// rotated returns the file now at the current path if it is not the
+// one being read, as after a rotation.  A file that was truncated is
+// read again from its start.  fl.mu must be held.
+func (fl *follower) rotated() (*os.File, error) {
+	fi, err := os.Stat(fl.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if fl.f != nil {
+		cur, err := fl.f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		if os.SameFile(fi, cur) {
+			off, err := fl.f.Seek(0, io.SeekCurrent)
+			if err == nil && fi.Size() < off {
+				_, err = fl.f.Seek(0, io.SeekStart)
+			}
+			return nil, err
+		}
+	}
+	f, err := os.Open(fl.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return f, err
+}
+
+// Close stops following.  A Read in progress returns ErrClosed.
+func (fl *follower) Close() error {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+	if fl.closed {
+		return ErrClosed
+	}
+	fl.closed = true
+	close(fl.done)
+	if fl.f != nil {
+		return fl.f.Close()
+	}
+	return nil
+}
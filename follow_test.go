@@ -0,0 +1,92 @@
+package rotate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTail(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt", WithOptions(Options{Max: 12, Keep: 10, NamePattern: namePatternDefault}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		fmt.Fprintf(x, "line %d\n", i)
+	}
+	o := FollowOptions{Options: x.Options()}
+	for n, expected := range map[int]string{
+		1:  "line 4\n",
+		2:  "line 3\nline 4\n",
+		4:  "line 1\nline 2\nline 3\nline 4\n",
+		10: "line 0\nline 1\nline 2\nline 3\nline 4\n",
+	} {
+		b, err := Tail(root, "mt", n, o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != expected {
+			t.Errorf("last %d lines: %q, expected %q", n, b, expected)
+		}
+	}
+	x.Close()
+}
+
+func TestFollow(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(14)
+	fmt.Fprintf(x, "old\nbefore\n")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rd, err := Follow(ctx, root, "mt", 1, FollowOptions{Poll: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for i := 0; i < 6; i++ {
+			fmt.Fprintf(x, "line %d\n", i)
+			time.Sleep(2 * time.Millisecond)
+		}
+	}()
+	expected := "before\nline 0\nline 1\nline 2\nline 3\nline 4\nline 5\n"
+	var got strings.Builder
+	buf := make([]byte, 3)
+	for got.Len() < len(expected) {
+		n, err := rd.Read(buf)
+		got.Write(buf[:n])
+		if err != nil && err != io.EOF {
+			t.Fatalf("after %q: %v", got.String(), err)
+		}
+	}
+	if got.String() != expected {
+		t.Errorf("followed %q, expected %q", got.String(), expected)
+	}
+	if err := rd.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rd.Read(buf); err != ErrClosed {
+		t.Errorf("Read after Close: %v, expected ErrClosed", err)
+	}
+	x.Close()
+}
@@ -0,0 +1,82 @@
+package rotate
+
+import (
+	"fmt"
+	"time"
+)
+
+// SyncPolicy controls how often written data is synced to stable
+// storage.
+type SyncPolicy struct {
+	// Interval is the longest that written data is left unsynced.
+	// The current file is synced that often while it has new
+	// data, and before it is rotated or closed.  Zero disables
+	// syncing.
+	Interval time.Duration
+
+	// DataOnly syncs with fdatasync where it is available, on
+	// Linux, which skips metadata such as the modification time
+	// that is not needed to read the data back.  This makes each
+	// sync cheaper for high rate audit logs.  Elsewhere the file
+	// is fully synced.
+	DataOnly bool
+}
+
+// SetSyncPolicy sets how often written data is synced.
+func (r *Writer) SetSyncPolicy(p SyncPolicy) {
+	r.Lock()
+	defer r.Unlock()
+	if r.syncStop != nil {
+		close(r.syncStop)
+		r.syncStop = nil
+	}
+	r.syncPolicy = p
+	if p.Interval <= 0 {
+		return
+	}
+	stop := make(chan struct{})
+	r.syncStop = stop
+	goLabeled("sync", r.prefix, func() {
+		t := time.NewTicker(p.Interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+			case <-stop:
+				return
+			case <-r.closing:
+				return
+			}
+			r.Lock()
+			if r.current != nil {
+				if err := r.syncDirty(); err != nil {
+					r.recordError(err)
+				}
+			}
+			r.Unlock()
+		}
+	})
+}
+
+// syncDirty syncs the current file if it has data that was written
+// since the last sync and a sync policy is set.  The lock must be
+// held.
+func (r *Writer) syncDirty() error {
+	if !r.dirty || r.syncPolicy.Interval <= 0 || r.passthrough || r.discard {
+		return nil
+	}
+	if err := r.flush(); err != nil {
+		return err
+	}
+	var err error
+	if r.syncPolicy.DataOnly {
+		err = datasync(r.current)
+	} else {
+		err = r.current.Sync()
+	}
+	if err != nil {
+		return fmt.Errorf("rotate: sync %s: %w", r.currentPath(), err)
+	}
+	r.dirty = false
+	return nil
+}
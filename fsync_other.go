@@ -0,0 +1,10 @@
+//go:build !linux
+
+package rotate
+
+import "os"
+
+// datasync syncs f fully, as fdatasync is not available.
+func datasync(f *os.File) error {
+	return f.Sync()
+}
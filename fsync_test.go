@@ -0,0 +1,37 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSyncPolicy(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt", WithBuffer(4096))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	for _, dataOnly := range []bool{false, true} {
+		x.SetSyncPolicy(SyncPolicy{Interval: time.Millisecond, DataOnly: dataOnly})
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+		waitFor(t, "sync", func() bool {
+			x.Lock()
+			defer x.Unlock()
+			return !x.dirty
+		})
+	}
+	x.SetSyncPolicy(SyncPolicy{})
+	if err := x.LastError(); err != nil {
+		t.Error(err)
+	}
+}
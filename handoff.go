@@ -0,0 +1,92 @@
+package rotate
+
+import "time"
+
+// State is what a Writer knows beyond its files, handed from one
+// process to the next in a graceful restart with Snapshot and
+// Restore.  It encodes as JSON, to be passed through a pipe or an
+// environment variable.
+type State struct {
+	// Counter is the number the next archive will be given.
+	Counter int `json:"counter"`
+
+	// Size is the size of the current file, which the new process
+	// cannot learn from the file when it is compressed.
+	Size int `json:"size"`
+
+	// Opened is when the current file was opened, for Triggers
+	// that rotate by age, and LastRotation is when the previous
+	// one was rotated.
+	Opened       time.Time `json:"opened"`
+	LastRotation time.Time `json:"last_rotation"`
+
+	// Sequence is the next sequence number, with WithSequence.
+	Sequence uint64 `json:"sequence,omitempty"`
+
+	// PendingEntries and PendingBytes are the writes still queued
+	// by an asynchronous Writer, which are not yet in the file.
+	PendingEntries int `json:"pending_entries"`
+	PendingBytes   int `json:"pending_bytes"`
+}
+
+// Snapshot returns the Writer's State, after writing out what it has
+// buffered.  So that no line is lost or reordered, the old process
+// should stop writing and Close the Writer, which drains its queue,
+// before taking the Snapshot it hands over; a closed Writer still
+// returns its State.
+func (r *Writer) Snapshot() (State, error) {
+	r.Lock()
+	defer r.Unlock()
+	if r.current != nil {
+		if err := r.flush(); err != nil {
+			return State{}, err
+		}
+	}
+	s := State{
+		Counter:      r.counter,
+		Size:         r.size,
+		Opened:       r.opened,
+		LastRotation: r.lastRotation,
+	}
+	if r.seq != nil {
+		s.Sequence = r.seq.next
+	}
+	if q := r.async; q != nil {
+		s.PendingEntries = len(q.ch)
+		q.bytesMu.Lock()
+		s.PendingBytes = q.bytes
+		q.bytesMu.Unlock()
+	}
+	return s, nil
+}
+
+// Restore carries on from s, the State of the Writer a previous
+// process had on the same root and prefix.  What the new Writer
+// found on disk wins where it is further along: the counter and the
+// sequence only move forward, and the size only grows.
+func (r *Writer) Restore(s State) error {
+	r.Lock()
+	defer r.Unlock()
+	if err := r.ready(); err != nil {
+		return err
+	}
+	if s.Counter > r.counter {
+		r.counter = s.Counter
+	}
+	if s.Size > r.size {
+		r.size = s.Size
+	}
+	if !s.Opened.IsZero() && s.Opened.Before(r.opened) {
+		r.opened = s.Opened
+	}
+	if s.LastRotation.After(r.lastRotation) {
+		r.lastRotation = s.LastRotation
+	}
+	if r.seq != nil && s.Sequence > r.seq.next {
+		r.seq.next = s.Sequence
+	}
+	if r.trigger != nil {
+		r.resetTrigger()
+	}
+	return nil
+}
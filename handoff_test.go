@@ -0,0 +1,63 @@
+package rotate
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestHandoff(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	old, err := New(root, "mt", WithLiveCompression(Gzip))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old.SetMax(20)
+	for i := 0; i < 3; i++ {
+		if _, err := old.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := old.Close(); err != nil {
+		t.Fatal(err)
+	}
+	st, err := old.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Counter != 1 || st.Size != 18 || st.PendingEntries != 0 {
+		t.Errorf("state: %+v", st)
+	}
+	b, err := json.Marshal(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the new process can not tell the size of the compressed file
+	x, err := New(root, "mt", WithLiveCompression(Gzip))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	x.SetMax(20)
+	var got State
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Restore(got); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path.Join(root, "mt_1.gz")); err != nil {
+		t.Errorf("restored size was not counted: %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package rotate
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Health describes the state of a Writer for monitoring, so that a
+// Writer that keeps failing to rotate, or to clean up or ship its
+// archives in the background, can be noticed even when the errors
+// are not seen by whoever writes to it.
+type Health struct {
+	// Err is the most recent error from a rotation or the work
+	// that follows it, and ErrTime is when it happened.
+	Err     error
+	ErrTime time.Time
+
+	// LastRotation is when the current file was last rotated.
+	LastRotation time.Time
+
+	// Size is the number of bytes in the current file.
+	Size int
+
+	// Closed reports whether Close has been called.
+	Closed bool
+}
+
+// Health returns the Writer's health.
+func (r *Writer) Health() Health {
+	r.Lock()
+	h := Health{LastRotation: r.lastRotation, Size: r.size, Closed: r.current == nil && !r.unopened}
+	r.Unlock()
+	r.errMu.Lock()
+	h.Err, h.ErrTime = r.lastErr, r.errTime
+	r.errMu.Unlock()
+	return h
+}
+
+// LastError returns the most recent error from a rotation or the
+// work that follows it, such as cleaning or storing archives in the
+// sink, or nil if there has been none.
+func (r *Writer) LastError() error {
+	r.errMu.Lock()
+	defer r.errMu.Unlock()
+	return r.lastErr
+}
+
+// recordError records err for Health and logs it.  It does not need
+// the lock.
+func (r *Writer) recordError(err error) {
+	r.logf("%s", strings.TrimPrefix(err.Error(), "rotate: "))
+	now := time.Now()
+	r.errMu.Lock()
+	r.lastErr = err
+	r.errTime = now
+	r.errMu.Unlock()
+	atomic.AddUint64(&r.stats.errors, 1)
+	r.emit(Event{Kind: EventError, Time: now, Err: err})
+}
@@ -0,0 +1,46 @@
+package rotate
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+type failSink struct{}
+
+func (failSink) Store(ctx context.Context, path string, info ArchiveInfo) error {
+	return errors.New("collector down")
+}
+
+func TestHealth(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := x.Health(); h.Err != nil || h.Closed || !h.LastRotation.IsZero() {
+		t.Errorf("new writer: %+v", h)
+	}
+	x.SetMax(5)
+	x.SetSink(failSink{})
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+	h := x.Health()
+	if h.Err == nil || h.Err != x.LastError() || h.ErrTime.IsZero() {
+		t.Errorf("after failed store: %+v", h)
+	}
+	if !h.Closed || h.LastRotation.IsZero() {
+		t.Errorf("after rotation and Close: %+v", h)
+	}
+}
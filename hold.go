@@ -0,0 +1,33 @@
+package rotate
+
+import "time"
+
+// SetHold sets whether the Writer is under hold, as when a device
+// is under a support escalation or legal hold.  While it is, no
+// archive is deleted by the keep limit, the maximum age, SetMinFree
+// or a Coordinator, though the current file is still rotated.  When
+// the filesystem is low on space by SetMinFree's limits, an
+// EventLowSpace is sent and logged at each rotation instead of
+// deleting archives, so the hold can be escalated before the disk
+// fills.  Retention resumes at the next rotation once the hold is
+// lifted.
+func (r *Writer) SetHold(on bool) {
+	r.Lock()
+	defer r.Unlock()
+	lay := *r.layout()
+	lay.hold = on
+	r.lay.Store(&lay)
+}
+
+// warnLowSpace sends EventLowSpace if the filesystem is low on space,
+// for a Writer under hold.  The lock must be held.
+func (r *Writer) warnLowSpace() error {
+	low, err := r.lowOnSpace()
+	if err != nil || !low {
+		return err
+	}
+	root := r.layout().root
+	r.logf("low on space under hold, not deleting archives in %s", root)
+	r.emit(Event{Kind: EventLowSpace, Time: time.Now(), Root: root})
+	return nil
+}
@@ -0,0 +1,63 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestHold(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	defer func() { diskFree = statDisk }()
+	diskFree = func(dir string) (int64, int64, error) {
+		return 0, 1 << 20, nil
+	}
+	x.SetMax(6)
+	x.SetKeep(1)
+	x.SetMinFree(1<<20, 0)
+	x.SetHold(true)
+	events := x.Events()
+	for i := 0; i < 3; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 3 {
+		t.Errorf("archives under hold: %+v", archives)
+	}
+	low := 0
+	for len(events) > 0 {
+		switch e := <-events; e.Kind {
+		case EventLowSpace:
+			low++
+		case EventDeleted:
+			t.Errorf("deleted %s under hold", e.Archive.Name)
+		}
+	}
+	if low != 3 {
+		t.Errorf("%d low space events", low)
+	}
+
+	x.SetHold(false)
+	x.SetMinFree(0, 0)
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if archives, _ := x.Archives(); len(archives) != 1 || archives[0].Name != "mt_4" {
+		t.Errorf("archives after hold: %+v", archives)
+	}
+}
@@ -0,0 +1,119 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// HTTPHandler returns an http.Handler that serves the logs written
+// by w.  A request for the handler's root returns a JSON listing of
+// the archives, a request for "current" streams the current file,
+// and a request for an archive name downloads that archive.
+// Responses are gzip compressed when the client accepts it.
+//
+// Mount it with http.StripPrefix when it is not served at "/":
+//
+//	http.Handle("/logs/", http.StripPrefix("/logs", rotate.HTTPHandler(w)))
+func HTTPHandler(w *Writer) http.Handler {
+	return &logHandler{w: w}
+}
+
+type logHandler struct {
+	w *Writer
+}
+
+type logListing struct {
+	Current  ArchiveInfo   `json:"current"`
+	Archives []ArchiveInfo `json:"archives"`
+}
+
+func (h *logHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		rw.Header().Set("Allow", "GET, HEAD")
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	archives, err := h.w.Archives()
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+req.URL.Path), "/")
+	switch name {
+	case "":
+		h.serveListing(rw, archives)
+	case "current":
+		cp := h.w.currentPath()
+		h.serveFile(rw, req, cp, path.Base(cp))
+	default:
+		for _, a := range archives {
+			if a.Name == name {
+				h.serveFile(rw, req, a.Path, a.Name)
+				return
+			}
+		}
+		http.NotFound(rw, req)
+	}
+}
+
+func (h *logHandler) serveListing(rw http.ResponseWriter, archives []ArchiveInfo) {
+	listing := logListing{Archives: archives}
+	if listing.Archives == nil {
+		listing.Archives = []ArchiveInfo{}
+	}
+	cp := h.w.currentPath()
+	listing.Current = ArchiveInfo{Name: path.Base(cp), Path: cp}
+	if fi, err := os.Stat(cp); err == nil {
+		listing.Current.Size = fi.Size()
+		listing.Current.ModTime = fi.ModTime()
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(listing)
+}
+
+// serveFile sends the file at p, compressing it on the fly if the
+// client accepts gzip.
+func (h *logHandler) serveFile(rw http.ResponseWriter, req *http.Request, p, name string) {
+	f, err := os.Open(p)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+
+	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	rw.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	if !acceptsGzip(req) {
+		fi, err := f.Stat()
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http.ServeContent(rw, req, name, fi.ModTime(), f)
+		return
+	}
+
+	rw.Header().Set("Content-Encoding", "gzip")
+	rw.Header().Add("Vary", "Accept-Encoding")
+	if req.Method == http.MethodHead {
+		return
+	}
+	gz := gzip.NewWriter(rw)
+	io.Copy(gz, f)
+	gz.Close()
+}
+
+func acceptsGzip(req *http.Request) bool {
+	for _, enc := range strings.Split(req.Header.Get("Accept-Encoding"), ",") {
+		if strings.TrimSpace(strings.SplitN(enc, ";", 2)[0]) == "gzip" {
+			return true
+		}
+	}
+	return false
+}
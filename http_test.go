@@ -0,0 +1,69 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(5)
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.Write([]byte("cur")); err != nil {
+		t.Fatal(err)
+	}
+	h := HTTPHandler(x)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	var listing logListing
+	if err := json.NewDecoder(rec.Body).Decode(&listing); err != nil {
+		t.Fatal(err)
+	}
+	if len(listing.Archives) != 1 || listing.Archives[0].Name != "mt_1" {
+		t.Errorf("archives: %+v, expected mt_1", listing.Archives)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/current", nil))
+	if rec.Body.String() != "cur" {
+		t.Errorf("current: %q, expected %q", rec.Body.String(), "cur")
+	}
+
+	req := httptest.NewRequest("GET", "/mt_1", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "hello\n" {
+		t.Errorf("mt_1: %q, expected %q", body, "hello\n")
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/../etc/passwd", nil))
+	if rec.Code != 404 {
+		t.Errorf("status: %d, expected 404", rec.Code)
+	}
+}
@@ -0,0 +1,172 @@
+package rotate
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"time"
+)
+
+// defaultTimeLayouts are tried, in order, to parse the timestamp at
+// the start of a line.  The first is the format used by the log
+// package with log.LstdFlags.
+var defaultTimeLayouts = []string{
+	"2006/01/02 15:04:05",
+	time.RFC3339Nano,
+}
+
+// tailScan is how much of the end of an uncompressed archive is
+// read to find its last timestamp.
+const tailScan = 64 * 1024
+
+// timeRange holds the first and last timestamps found in an archive.
+// An archive with no parsable timestamps has a zero first.
+type timeRange struct {
+	size        int64
+	first, last time.Time
+}
+
+// SetTimeLayouts sets the layouts, as used by time.Parse, that are
+// tried to read the timestamp at the start of each line when
+// indexing archives by time.  The defaults handle the log package's
+// standard format and RFC 3339.
+func (r *Writer) SetTimeLayouts(layouts ...string) {
+	r.indexMu.Lock()
+	r.timeLayouts = layouts
+	r.index = nil
+	r.indexMu.Unlock()
+}
+
+// ArchivesBetween returns the archives, oldest first, that contain
+// lines timestamped between from and to inclusive.  The first and
+// last timestamps of each archive are read once and cached.
+// Archives without any timestamps that could be parsed are always
+// returned, so that nothing is missed.
+func (r *Writer) ArchivesBetween(from, to time.Time) ([]ArchiveInfo, error) {
+	archives, err := r.Archives()
+	if err != nil {
+		return nil, err
+	}
+
+	r.indexMu.Lock()
+	defer r.indexMu.Unlock()
+	index := make(map[string]timeRange, len(archives))
+	var found []ArchiveInfo
+	for _, a := range archives {
+		tr, ok := r.index[a.Name]
+		if !ok || tr.size != a.Size {
+			tr, err = r.scanTimes(a)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		index[a.Name] = tr
+		if tr.first.IsZero() || (!tr.last.Before(from) && !tr.first.After(to)) {
+			found = append(found, a)
+		}
+	}
+	r.index = index
+	return found, nil
+}
+
+// scanTimes finds the first and last timestamps in an archive.
+func (r *Writer) scanTimes(a ArchiveInfo) (timeRange, error) {
+	tr := timeRange{size: a.Size}
+	rc, err := openDecompressed(a.Path, r.keyProvider())
+	if err != nil {
+		return tr, err
+	}
+	defer rc.Close()
+
+	br := bufio.NewReader(rc)
+	for tr.first.IsZero() {
+		line, err := br.ReadBytes('\n')
+		if t, ok := r.parseTime(line); ok {
+			tr.first, tr.last = t, t
+		}
+		if err == io.EOF {
+			return tr, nil
+		}
+		if err != nil {
+			return tr, err
+		}
+	}
+
+	if a.Size > tailScan {
+		if t, ok := r.tailTime(a.Path, a.Size); ok {
+			tr.last = t
+			return tr, nil
+		}
+	}
+	for {
+		line, err := br.ReadBytes('\n')
+		if t, ok := r.parseTime(line); ok {
+			tr.last = t
+		}
+		if err == io.EOF {
+			return tr, nil
+		}
+		if err != nil {
+			return tr, err
+		}
+	}
+}
+
+// tailTime returns the last timestamp in the final tailScan bytes
+// of an uncompressed file.
+func (r *Writer) tailTime(p string, size int64) (time.Time, bool) {
+	var last time.Time
+	f, err := os.Open(p)
+	if err != nil {
+		return last, false
+	}
+	defer f.Close()
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(f, magic); err != nil || (magic[0] == 0x1f && magic[1] == 0x8b) {
+		return last, false
+	}
+	if _, err := f.Seek(size-tailScan, io.SeekStart); err != nil {
+		return last, false
+	}
+	br := bufio.NewReader(f)
+	// skip the partial line the seek landed in
+	if _, err := br.ReadBytes('\n'); err != nil {
+		return last, false
+	}
+	for {
+		line, err := br.ReadBytes('\n')
+		if t, ok := r.parseTime(line); ok {
+			last = t
+		}
+		if err != nil {
+			return last, !last.IsZero()
+		}
+	}
+}
+
+// parseTime parses the timestamp at the start of line, which may be
+// one or two space-separated fields long.  Timestamps without a
+// zone are taken to be local time, as the log package writes them.
+func (r *Writer) parseTime(line []byte) (time.Time, bool) {
+	layouts := r.timeLayouts
+	if layouts == nil {
+		layouts = defaultTimeLayouts
+	}
+	fields := bytes.SplitN(line, []byte(" "), 3)
+	candidates := []string{string(bytes.TrimSpace(fields[0]))}
+	if len(fields) > 1 {
+		candidates = append(candidates, string(fields[0])+" "+string(bytes.TrimSpace(fields[1])))
+	}
+	for _, layout := range layouts {
+		for _, c := range candidates {
+			if t, err := time.ParseInLocation(layout, c, time.Local); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,47 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestArchivesBetween(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(1)
+	lines := []string{
+		"2020-01-01T10:00:00Z a\n2020-01-01T11:00:00Z b\n",
+		"2020-01-02T10:00:00Z c\n2020-01-02T11:00:00Z d\n",
+		"no timestamp\n",
+		"2020/01/03 10:00:00 e\n",
+	}
+	for _, s := range lines {
+		if _, err := x.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	from := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	to := time.Date(2020, 1, 2, 10, 30, 0, 0, time.UTC)
+	found, err := x.ArchivesBetween(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, a := range found {
+		names = append(names, a.Name)
+	}
+	if len(names) != 2 || names[0] != "mt_2" || names[1] != "mt_3" {
+		t.Errorf("archives: %v, expected [mt_2 mt_3]", names)
+	}
+}
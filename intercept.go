@@ -0,0 +1,103 @@
+package rotate
+
+import (
+	"bytes"
+	"regexp"
+	"time"
+)
+
+// A WriteInterceptor transforms the data passed to Write before it
+// is written to the file.  p belongs to the caller of Write and must
+// not be modified; return p itself or a new slice.  Returning an
+// empty slice drops the write.
+// Interceptors are called with the Writer's lock held, so they run
+// one at a time and may keep state between calls.
+type WriteInterceptor func(p []byte) []byte
+
+// Use appends interceptors to the chain that every Write passes
+// through.  They are applied in the order they were added.
+func (r *Writer) Use(fns ...WriteInterceptor) {
+	r.Lock()
+	r.interceptors = append(r.interceptors, fns...)
+	r.Unlock()
+}
+
+// writeIntercepted runs p through the interceptors and writes the
+// result.  Since the written data may differ in length from p, it
+// reports len(p) on success.
+func (r *Writer) writeIntercepted(p []byte) (int, error) {
+	b := r.intercept(p)
+	if len(b) == 0 {
+		return len(p), nil
+	}
+	if _, err := r.write(b); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// intercepting reports whether writes must go through intercept.
+// The lock must be held.
+func (r *Writer) intercepting() bool {
+	return len(r.interceptors) > 0 || r.seq != nil
+}
+
+// intercept runs p through the interceptors and returns the result,
+// which is empty if p is dropped.  Sequence numbers are added last.
+// The lock must be held.
+func (r *Writer) intercept(p []byte) []byte {
+	b := p
+	for _, fn := range r.interceptors {
+		b = fn(b)
+		if len(b) == 0 {
+			return nil
+		}
+	}
+	if r.seq != nil {
+		b = r.seq.prefix(b)
+	}
+	return b
+}
+
+// TimestampPrefix returns a WriteInterceptor that starts every line
+// with the current time formatted with layout, followed by a space.
+// Lines split over several writes get a single timestamp.  This is
+// useful when the producer, such as a subprocess's stdout, writes
+// bare lines.
+func TimestampPrefix(layout string) WriteInterceptor {
+	lineStart := true
+	return func(p []byte) []byte {
+		ts := append([]byte(time.Now().Format(layout)), ' ')
+		out := make([]byte, 0, len(p)+len(ts))
+		for len(p) > 0 {
+			if lineStart {
+				out = append(out, ts...)
+			}
+			i := bytes.IndexByte(p, '\n')
+			if i < 0 {
+				out = append(out, p...)
+				lineStart = false
+				break
+			}
+			out = append(out, p[:i+1]...)
+			p = p[i+1:]
+			lineStart = true
+		}
+		return out
+	}
+}
+
+// ansiEscape matches ANSI CSI sequences, such as color codes, and
+// OSC sequences, such as terminal title changes.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+
+// StripANSI is a WriteInterceptor that removes ANSI escape sequences,
+// such as the color codes many daemons write to stdout:
+//
+//	w.Use(rotate.StripANSI)
+func StripANSI(p []byte) []byte {
+	if bytes.IndexByte(p, 0x1b) < 0 {
+		return p
+	}
+	return ansiEscape.ReplaceAll(p, nil)
+}
@@ -0,0 +1,68 @@
+package rotate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"testing"
+)
+
+func TestUse(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.Use(
+		func(p []byte) []byte {
+			if bytes.HasPrefix(p, []byte("debug")) {
+				return nil
+			}
+			return p
+		},
+		bytes.ToUpper,
+	)
+	for _, s := range []string{"hello\n", "debug noise\n", "world\n"} {
+		n, err := x.Write([]byte(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != len(s) {
+			t.Errorf("n: %d, expected %d", n, len(s))
+		}
+	}
+
+	data, err := ioutil.ReadFile(path.Join(root, fileDefault))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "HELLO\nWORLD\n" {
+		t.Errorf("file: %q, expected %q", data, "HELLO\nWORLD\n")
+	}
+}
+
+func TestTimestampPrefix(t *testing.T) {
+	ts := TimestampPrefix("2006-01-02")
+	var out []byte
+	for _, s := range []string{"one\ntw", "o\n", "three\n"} {
+		out = append(out, ts([]byte(s))...)
+	}
+	re := regexp.MustCompile(`^\d{4}-\d\d-\d\d one\n\d{4}-\d\d-\d\d two\n\d{4}-\d\d-\d\d three\n$`)
+	if !re.Match(out) {
+		t.Errorf("output: %q", out)
+	}
+}
+
+func TestStripANSI(t *testing.T) {
+	in := "\x1b[1;31mERROR\x1b[0m disk \x1b]0;title\x07full\n"
+	if out := string(StripANSI([]byte(in))); out != "ERROR disk full\n" {
+		t.Errorf("StripANSI(%q): %q, expected %q", in, out, "ERROR disk full\n")
+	}
+}
@@ -0,0 +1,116 @@
+package rotate
+
+import (
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// SetDatedDirs sets whether archives are placed in dated
+// subdirectories, as root/YYYY/MM/DD/prefix_N, using the
+// date of the rotation.  This keeps the number of entries in each
+// directory manageable.  Archive names returned by Archives are
+// then relative to root, such as "2024/05/01/prefix_3".
+func (r *Writer) SetDatedDirs(on bool) {
+	r.Lock()
+	defer r.Unlock()
+	lay := *r.layout()
+	lay.dated = on
+	r.lay.Store(&lay)
+}
+
+// layout describes where a Writer's archives are, how they are
+// named and how changes to them are recorded.  It is
+// replaced as a whole, with the lock held, and never
+// modified, so the archives can be listed without the lock, which
+// may be held for a long time by a rotation.
+type layout struct {
+	root        string
+	archDir     string
+	dated       bool
+	namePattern string
+	nameRE      *regexp.Regexp
+	syncDirs    bool
+	manifest    bool
+	readOnly    bool
+	immutable   bool
+	hold        bool
+	exempt      []string
+}
+
+func (r *Writer) layout() *layout {
+	return r.lay.Load().(*layout)
+}
+
+// makeDatedDir creates the directory for archives rotated at t and
+// returns its path relative to root.
+func (r *Writer) makeDatedDir(t time.Time) (string, error) {
+	dir := t.Format("2006/01/02")
+	if err := r.mkdirAll(path.Join(r.archiveRoot(), dir)); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// datedDirs returns the YYYY/MM/DD directories under root, relative
+// to root, in order.
+func (r *Writer) datedDirs() ([]string, error) {
+	dirs := []string{""}
+	for _, width := range []int{4, 2, 2} {
+		var next []string
+		for _, dir := range dirs {
+			names, err := numericDirs(path.Join(r.archiveRoot(), dir), width)
+			if err != nil {
+				return nil, err
+			}
+			for _, n := range names {
+				next = append(next, path.Join(dir, n))
+			}
+		}
+		dirs = next
+	}
+	return dirs, nil
+}
+
+// numericDirs returns the subdirectories of dir whose names are
+// width digits long, sorted.
+func numericDirs(dir string, width int) ([]string, error) {
+	d, err := os.Open(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer d.Close()
+	fis, err := d.Readdir(-1)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, fi := range fis {
+		if !fi.IsDir() || len(fi.Name()) != width {
+			continue
+		}
+		if _, err := strconv.Atoi(fi.Name()); err != nil {
+			continue
+		}
+		names = append(names, fi.Name())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// removeEmptyDirs removes dir, relative to root, and its parents up
+// to root, stopping at the first one that is not empty.
+func (r *Writer) removeEmptyDirs(dir string) {
+	for dir != "." && dir != "/" && dir != "" {
+		if err := os.Remove(path.Join(r.archiveRoot(), dir)); err != nil {
+			return
+		}
+		dir = path.Dir(dir)
+	}
+}
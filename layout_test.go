@@ -0,0 +1,51 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestDatedDirs(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	// an archive left by an earlier run
+	old := path.Join(root, "2000", "01", "01")
+	if err := os.MkdirAll(old, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(old, "mt_0"), []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(1)
+	x.SetKeep(2)
+	x.SetDatedDirs(true)
+	for i := 0; i < 3; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	today := time.Now().Format("2006/01/02")
+	if len(archives) != 2 || archives[0].Name != today+"/mt_2" || archives[1].Name != today+"/mt_3" {
+		t.Errorf("archives: %+v", archives)
+	}
+	if _, err := os.Stat(path.Join(root, "2000")); !os.IsNotExist(err) {
+		t.Errorf("empty dated directory not removed: %v", err)
+	}
+}
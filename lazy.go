@@ -0,0 +1,27 @@
+package rotate
+
+// WithLazyOpen makes New put off creating the root and the current
+// file, and the recovery work that goes with them, until the first
+// write, so a component that logs nothing in a run leaves nothing
+// behind.  Errors New would have returned are returned by that
+// write instead, and the next write tries again.
+func WithLazyOpen() Option {
+	return func(r *Writer) {
+		r.lazy = true
+	}
+}
+
+// ready opens the current file if New put it off, and returns
+// ErrClosed if the Writer is closed.  The lock must be held.
+func (r *Writer) ready() error {
+	if r.unopened {
+		if err := r.open(); err != nil {
+			return err
+		}
+		r.unopened = false
+	}
+	if r.current == nil {
+		return ErrClosed
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestLazyOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	root := path.Join(dir, "logs")
+	x, err := New(root, "mt", WithLazyOpen())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Fatalf("root created before the first write: %v", err)
+	}
+	if err := x.Ping(); err != nil {
+		t.Errorf("ping: %v", err)
+	}
+	if h := x.Health(); h.Closed {
+		t.Errorf("closed before the first write")
+	}
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path.Join(root, fileDefault))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hello\n" {
+		t.Errorf("got %q", b)
+	}
+
+	// a Writer that never writes leaves nothing behind
+	unused := path.Join(dir, "unused")
+	y, err := New(unused, "mt", WithLazyOpen())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := y.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := y.Write([]byte("late\n")); err != ErrClosed {
+		t.Errorf("write after close: %v", err)
+	}
+	if _, err := os.Stat(unused); !os.IsNotExist(err) {
+		t.Errorf("root created by an unused Writer: %v", err)
+	}
+}
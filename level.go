@@ -0,0 +1,100 @@
+package rotate
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Level is the severity of a log line, as read by LevelFilter.
+type Level int
+
+const (
+	LevelTrace Level = iota
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+var levelNames = map[string]Level{
+	"TRACE":    LevelTrace,
+	"DEBUG":    LevelDebug,
+	"DBG":      LevelDebug,
+	"INFO":     LevelInfo,
+	"INF":      LevelInfo,
+	"NOTICE":   LevelInfo,
+	"WARN":     LevelWarn,
+	"WARNING":  LevelWarn,
+	"WRN":      LevelWarn,
+	"ERROR":    LevelError,
+	"ERR":      LevelError,
+	"FATAL":    LevelFatal,
+	"CRIT":     LevelFatal,
+	"CRITICAL": LevelFatal,
+	"PANIC":    LevelFatal,
+}
+
+// ParseLevel returns the level named by s, such as "info" or
+// "WARNING", ignoring case.
+func ParseLevel(s string) (Level, bool) {
+	l, ok := levelNames[strings.ToUpper(s)]
+	return l, ok
+}
+
+// lineLevel returns the level named by the first token of line,
+// which may be wrapped in brackets or followed by a colon, as in
+// "[WARN]" or "error:".
+func lineLevel(line []byte) (Level, bool) {
+	line = bytes.TrimLeft(line, " \t")
+	end := bytes.IndexAny(line, " \t\r\n")
+	if end < 0 {
+		end = len(line)
+	}
+	tok := bytes.Trim(line[:end], "[]<>:")
+	return ParseLevel(string(tok))
+}
+
+// LevelFilter returns a WriteInterceptor that drops lines whose
+// leading level token is below min, so the verbosity of what reaches
+// the file can be reduced without changing the application's logger:
+//
+//	w.Use(rotate.LevelFilter(rotate.LevelInfo))
+//
+// Lines without a level token, such as the lines of a stack trace,
+// are kept or dropped with the line before them, and are kept at the
+// start.  Lines split over several writes are handled as one.
+func LevelFilter(min Level) WriteInterceptor {
+	lineStart := true
+	drop := false
+	return func(p []byte) []byte {
+		var out []byte
+		kept := 0
+		for off := 0; off < len(p); {
+			if lineStart {
+				if l, ok := lineLevel(p[off:]); ok {
+					drop = l < min
+				}
+			}
+			end := len(p)
+			lineStart = false
+			if i := bytes.IndexByte(p[off:], '\n'); i >= 0 {
+				end = off + i + 1
+				lineStart = true
+			}
+			if drop {
+				if out == nil {
+					out = make([]byte, 0, len(p))
+				}
+				out = append(out, p[kept:off]...)
+				kept = end
+			}
+			off = end
+		}
+		if out == nil {
+			// nothing dropped
+			return p
+		}
+		return append(out, p[kept:]...)
+	}
+}
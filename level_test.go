@@ -0,0 +1,21 @@
+package rotate
+
+import "testing"
+
+func TestLevelFilter(t *testing.T) {
+	f := LevelFilter(LevelInfo)
+	var out []byte
+	for _, s := range []string{
+		"INFO starting\nDEBUG noise\n",
+		"[debug] more noise\n  continued\n",
+		"WARN: disk",
+		" low\nerror failed\n\tat main.go:10\n",
+		"DEBUG only noise\n",
+	} {
+		out = append(out, f([]byte(s))...)
+	}
+	expected := "INFO starting\nWARN: disk low\nerror failed\n\tat main.go:10\n"
+	if string(out) != expected {
+		t.Errorf("output: %q, expected %q", out, expected)
+	}
+}
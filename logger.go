@@ -0,0 +1,47 @@
+package rotate
+
+import "log"
+
+// A Logger receives messages about what a Writer does internally:
+// rotations, deletions, files being opened and recovery actions.
+// *log.Logger implements it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// loggerHolder lets a nil Logger be stored in an atomic.Value.
+type loggerHolder struct {
+	l Logger
+}
+
+// WithLogger sets the Logger for the Writer, so that the recovery
+// actions of New are logged too.
+func WithLogger(l Logger) Option {
+	return func(r *Writer) {
+		r.logger.Store(loggerHolder{l})
+	}
+}
+
+// SetLogger sets the Logger for internal events.  A nil Logger turns
+// logging off, which is the default.
+func (r *Writer) SetLogger(l Logger) {
+	r.logger.Store(loggerHolder{l})
+}
+
+// logf logs a message if a Logger is set.  It does not need the lock.
+func (r *Writer) logf(format string, v ...interface{}) {
+	if h, ok := r.logger.Load().(loggerHolder); ok && h.l != nil {
+		h.l.Printf("rotate: "+format, v...)
+	}
+}
+
+// NewStdLogger returns a *log.Logger with the given flags that writes
+// to a new Writer, along with the Writer so the caller can configure
+// and close it.
+func NewStdLogger(root, prefix string, flags int, opts ...Option) (*log.Logger, *Writer, error) {
+	w, err := New(root, prefix, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return log.New(w, "", flags), w, nil
+}
@@ -0,0 +1,63 @@
+package rotate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	if err := ioutil.WriteFile(path.Join(root, "mt_7.tmp"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	x, err := New(root, "mt", WithLogger(log.New(&buf, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(1)
+	x.SetKeep(0)
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{"removed temporary file", "opened", "rotated default.log", "deleted"} {
+		if !strings.Contains(buf.String(), "rotate: "+s) {
+			t.Errorf("no %q in log:\n%s", s, buf.String())
+		}
+	}
+}
+
+func TestNewStdLogger(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	l, w, err := NewStdLogger(root, "mt", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Printf("hello %d", 1)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path.Join(root, fileDefault))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hello 1\n" {
+		t.Errorf("got %q", b)
+	}
+}
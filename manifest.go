@@ -0,0 +1,94 @@
+package rotate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+)
+
+// manifestSuffix is appended to the prefix to name the manifest in
+// the root directory.
+const manifestSuffix = ".manifest.json"
+
+// A Manifest lists a Writer's current file and archives.  It is
+// kept in the root directory as <prefix>.manifest.json, so shippers
+// and other tools have a single index of the logs.
+type Manifest struct {
+	Prefix   string        `json:"prefix"`
+	Updated  time.Time     `json:"updated"`
+	Current  ArchiveInfo   `json:"current"`
+	Archives []ArchiveInfo `json:"archives"`
+
+	// Chain is the hash chain of the archives, if SetHashChain
+	// turned it on.
+	Chain []ChainLink `json:"chain,omitempty"`
+}
+
+// SetManifest sets whether the Writer maintains a manifest in the
+// root directory.  It is replaced atomically whenever the archives
+// change.
+func (r *Writer) SetManifest(on bool) error {
+	r.Lock()
+	lay := *r.layout()
+	lay.manifest = on
+	r.lay.Store(&lay)
+	r.Unlock()
+	return r.writeManifest()
+}
+
+// ManifestPath returns the path of the Writer's manifest.
+func (r *Writer) ManifestPath() string {
+	return path.Join(r.layout().root, r.prefix+manifestSuffix)
+}
+
+// ReadManifest reads the manifest at p.
+func ReadManifest(p string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	m := new(Manifest)
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// writeManifest replaces the manifest, if it is enabled.  It does
+// not need the lock.
+func (r *Writer) writeManifest() error {
+	if !r.layout().manifest {
+		return nil
+	}
+	r.manifestMu.Lock()
+	defer r.manifestMu.Unlock()
+
+	archives, err := r.Archives()
+	if err != nil {
+		return fmt.Errorf("rotate: write manifest: %w", err)
+	}
+	if archives == nil {
+		archives = []ArchiveInfo{}
+	}
+	m := Manifest{Prefix: r.prefix, Updated: time.Now(), Archives: archives, Chain: r.chain}
+	cp := r.currentPath()
+	m.Current = ArchiveInfo{Name: path.Base(cp), Path: cp}
+	if fi, err := os.Stat(cp); err == nil {
+		m.Current.Size = fi.Size()
+		m.Current.ModTime = fi.ModTime()
+	}
+	data, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(r.ManifestPath(), append(data, '\n'), r.filePerm); err != nil {
+		return fmt.Errorf("rotate: write manifest: %w", err)
+	}
+	if err := r.fixPerm(r.ManifestPath()); err != nil {
+		return fmt.Errorf("rotate: set permissions of manifest: %w", err)
+	}
+	return nil
+}
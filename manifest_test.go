@@ -0,0 +1,45 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := x.SetManifest(true); err != nil {
+		t.Fatal(err)
+	}
+	x.SetSidecar(true)
+	x.SetMax(5)
+	x.SetKeep(2)
+	for i := 0; i < 3; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := x.Write([]byte("cur")); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := ReadManifest(x.ManifestPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Prefix != "mt" || m.Current.Name != fileDefault {
+		t.Errorf("manifest: %+v", m)
+	}
+	if len(m.Archives) != 2 || m.Archives[0].Name != "mt_2" || m.Archives[1].Meta == nil {
+		t.Errorf("manifest archives: %+v", m.Archives)
+	}
+}
@@ -0,0 +1,35 @@
+package rotate
+
+import "bytes"
+
+// SetRotateMarker makes a write containing marker, such as a
+// "=== BOOT ===" banner, rotate the current file before it is
+// written, so each boot cycle or test run starts a fresh file.  The
+// file is not rotated if it is empty.  A nil marker turns it off,
+// which is the default.
+func (r *Writer) SetRotateMarker(marker []byte) {
+	r.Lock()
+	r.marker = append([]byte(nil), marker...)
+	r.Unlock()
+}
+
+// marked reports whether p holds the rotation marker.  The lock must
+// be held.
+func (r *Writer) marked(p []byte) bool {
+	return len(r.marker) > 0 && bytes.Contains(p, r.marker)
+}
+
+// markerIndex returns the index of the first of bufs after the first
+// that holds the rotation marker, or 0 if none does.  The lock must
+// be held.
+func (r *Writer) markerIndex(bufs [][]byte) int {
+	if len(r.marker) == 0 {
+		return 0
+	}
+	for i := 1; i < len(bufs); i++ {
+		if r.marked(bufs[i]) {
+			return i
+		}
+	}
+	return 0
+}
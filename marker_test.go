@@ -0,0 +1,45 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestRotateMarker(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	x.SetRotateMarker([]byte("=== BOOT ==="))
+	for _, s := range []string{"=== BOOT ===\n", "a\n", "=== BOOT ===\n", "b\n"} {
+		if _, err := x.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := x.WriteBatch([][]byte{[]byte("c\n"), []byte("=== BOOT ===\n"), []byte("d\n")}); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"mt_1":      "=== BOOT ===\na\n",
+		"mt_2":      "=== BOOT ===\nb\nc\n",
+		fileDefault: "=== BOOT ===\nd\n",
+	} {
+		b, err := ioutil.ReadFile(path.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("%s: %q, expected %q", name, b, want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package rotate
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// metaSuffix is appended to an archive's name to name its sidecar
+// metadata file.
+const metaSuffix = ".meta.json"
+
+var newline = []byte{'\n'}
+
+// ArchiveMeta is the metadata recorded for an archive in a sidecar
+// file next to it, named after it with a .meta.json suffix.
+type ArchiveMeta struct {
+	// Start is when the file became the current file and End is
+	// when it was rotated.
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+
+	// Bytes and Lines count the uncompressed data.
+	Bytes int64 `json:"bytes"`
+	Lines int64 `json:"lines"`
+
+	// SHA256 is the hex encoded checksum of the archive file as
+	// stored.
+	SHA256 string `json:"sha256"`
+
+	// Compression is the extension of the compressor used, such
+	// as "gz", or empty.
+	Compression string `json:"compression,omitempty"`
+
+	// KeyIDs are the IDs of the keys the archive is encrypted
+	// with, if it is, in the order they are used in the file.
+	KeyIDs []string `json:"key_ids,omitempty"`
+
+	// Reason is why the archive was rotated.
+	Reason RotationReason `json:"reason,omitempty"`
+
+	// Tags are set by TagCurrent and TagArchive.
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// SetSidecar sets whether a metadata file is written next to each
+// archive when it is rotated.  The metadata is returned by Archives.
+func (r *Writer) SetSidecar(on bool) {
+	r.Lock()
+	r.sidecar = on
+	r.Unlock()
+}
+
+// writeMeta records the metadata of an archive that was the current
+// file from start until end, was rotated for reason, was compressed
+// with live, if not nil, and was tagged with tags.
+func writeMeta(a ArchiveInfo, start, end time.Time, live Compressor, reason RotationReason, tags map[string]string, perm os.FileMode) (*ArchiveMeta, error) {
+	meta := &ArchiveMeta{Start: start, End: end, Reason: reason, Tags: tags}
+	if live != nil {
+		meta.Compression = strings.TrimPrefix(live.Ext(), ".")
+	}
+
+	f, err := os.Open(a.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	// The archive is read back, rather than counted as it is
+	// written, so data appended by an earlier run is included.
+	h := sha256.New()
+	src := io.TeeReader(f, h)
+	if live != nil {
+		rc, err := live.NewReader(bufio.NewReader(src))
+		if err != nil {
+			return nil, err
+		}
+		meta.Bytes, meta.Lines, err = countLines(rc)
+		meta.KeyIDs = keyIDs(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		// the compressed stream may be followed by padding
+		if _, err := io.Copy(ioutil.Discard, src); err != nil {
+			return nil, err
+		}
+	} else {
+		if meta.Bytes, meta.Lines, err = countLines(src); err != nil {
+			return nil, err
+		}
+	}
+	meta.SHA256 = hex.EncodeToString(h.Sum(nil))
+
+	data, err := json.MarshalIndent(meta, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(a.Path+metaSuffix, append(data, '\n'), perm); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// countLines returns the number of bytes and newlines in rd.
+func countLines(rd io.Reader) (n, lines int64, err error) {
+	buf := make([]byte, 32*1024)
+	for {
+		m, err := rd.Read(buf)
+		n += int64(m)
+		lines += int64(bytes.Count(buf[:m], newline))
+		if err == io.EOF {
+			return n, lines, nil
+		}
+		if err != nil {
+			return n, lines, err
+		}
+	}
+}
+
+// readMeta returns the metadata recorded for the archive at p, or
+// nil if there is none.
+func readMeta(p string) *ArchiveMeta {
+	data, err := ioutil.ReadFile(p + metaSuffix)
+	if err != nil {
+		return nil
+	}
+	meta := new(ArchiveMeta)
+	if err := json.Unmarshal(data, meta); err != nil {
+		return nil
+	}
+	return meta
+}
+
+// writeFileAtomic writes data to p through a temporary file, so p
+// is never seen partially written.
+func writeFileAtomic(p string, data []byte, perm os.FileMode) error {
+	tmp := p + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, perm); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package rotate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSidecar(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt", WithLiveCompression(Gzip))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetSidecar(true)
+	x.SetMax(12)
+	x.SetKeep(1)
+	for i := 0; i < 4; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 1 {
+		t.Fatalf("archives: %d, expected 1", len(archives))
+	}
+	a := archives[0]
+	if a.Meta == nil {
+		t.Fatal("no metadata")
+	}
+	data, err := ioutil.ReadFile(a.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	if a.Meta.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("sha256: %s, expected %x", a.Meta.SHA256, sum)
+	}
+	if a.Meta.Bytes != 12 || a.Meta.Lines != 2 || a.Meta.Compression != "gz" {
+		t.Errorf("meta: %+v", a.Meta)
+	}
+	if a.Meta.End.Before(a.Meta.Start) {
+		t.Errorf("end %v before start %v", a.Meta.End, a.Meta.Start)
+	}
+
+	// the first archive's sidecar was deleted with it
+	names, err := ioutil.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 3 {
+		t.Errorf("files in root: %d, expected 3", len(names))
+	}
+}
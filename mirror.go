@@ -0,0 +1,46 @@
+package rotate
+
+import "errors"
+
+// A Mirror writes everything to two Writers, typically with roots on
+// independent storage such as the local disk and a secondary
+// partition, and keeps working while either of them fails.  Each
+// Writer rotates and cleans up on its own.
+type Mirror struct {
+	w [2]*Writer
+}
+
+// NewMirror returns a Mirror that writes to a and b.
+func NewMirror(a, b *Writer) *Mirror {
+	return &Mirror{w: [2]*Writer{a, b}}
+}
+
+// Write writes p to both Writers.  It fails only if both of them do;
+// the failure of one is recorded for its Health.
+func (m *Mirror) Write(p []byte) (int, error) {
+	var errs [2]error
+	for i, w := range m.w {
+		if _, err := w.Write(p); err != nil {
+			errs[i] = err
+		}
+	}
+	switch {
+	case errs[0] != nil && errs[1] != nil:
+		return 0, errors.Join(errs[0], errs[1])
+	case errs[0] != nil:
+		m.w[0].recordError(errs[0])
+	case errs[1] != nil:
+		m.w[1].recordError(errs[1])
+	}
+	return len(p), nil
+}
+
+// Writers returns the two Writers, so their health can be checked.
+func (m *Mirror) Writers() (a, b *Writer) {
+	return m.w[0], m.w[1]
+}
+
+// Close closes both Writers.
+func (m *Mirror) Close() error {
+	return errors.Join(m.w[0].Close(), m.w[1].Close())
+}
@@ -0,0 +1,47 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestMirror(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	a, err := New(path.Join(root, "a"), "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(path.Join(root, "b"), "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMirror(a, b)
+	if _, err := m.Write([]byte("both\n")); err != nil {
+		t.Fatal(err)
+	}
+	b.Close()
+	if _, err := m.Write([]byte("only a\n")); err != nil {
+		t.Errorf("write with one side closed: %v", err)
+	}
+	if b.LastError() == nil {
+		t.Error("failure of b not recorded")
+	}
+	a.Close()
+	if _, err := m.Write([]byte("neither\n")); err == nil {
+		t.Error("write with both sides closed succeeded")
+	}
+
+	if s, _ := ioutil.ReadFile(path.Join(root, "a", "default.log")); string(s) != "both\nonly a\n" {
+		t.Errorf("a: %q", s)
+	}
+	if s, _ := ioutil.ReadFile(path.Join(root, "b", "default.log")); string(s) != "both\n" {
+		t.Errorf("b: %q", s)
+	}
+}
@@ -0,0 +1,69 @@
+package rotate
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// WithMultiline keeps multiline records, such as a Go panic's stack
+// trace, in one file: when the current file is due to be rotated, the
+// rotation waits until a write starts a new record.  A write
+// continues the previous record if the previous write did not end
+// with a newline, or if its first line does not match start.  With a
+// nil start, indented and empty lines are continuations.
+//
+// Since rotation then happens between writes, a write holding the
+// end of one record and the start of the next stays in one file.
+func WithMultiline(start *regexp.Regexp) Option {
+	return func(r *Writer) {
+		r.multiline = true
+		r.recordStart = start
+	}
+}
+
+// continues reports whether p continues the record last written.
+func (r *Writer) continues(p []byte) bool {
+	if r.midLine {
+		return true
+	}
+	line := p
+	if i := bytes.IndexByte(p, '\n'); i >= 0 {
+		line = p[:i]
+	}
+	if r.recordStart != nil {
+		return !r.recordStart.Match(line)
+	}
+	return len(line) == 0 || line[0] == ' ' || line[0] == '\t' || line[0] == '\r'
+}
+
+// rotateBefore rotates before p is written if p holds the rotation
+// marker, or makes a rotation that was held back for a multiline
+// record, if p starts a new one.  The lock must be held.
+func (r *Writer) rotateBefore(p []byte) error {
+	if r.size > 0 && r.marked(p) {
+		return r.rotate(ReasonMarker)
+	}
+	if !r.rotatePending || len(p) == 0 || r.continues(p) {
+		return nil
+	}
+	return r.rotate(r.dueReason)
+}
+
+// rotateAfter rotates once p has been written if due is set, or
+// holds the rotation back until the next record.  The lock must be
+// held.
+func (r *Writer) rotateAfter(p []byte, due bool) error {
+	if !r.multiline {
+		if due {
+			return r.rotate(r.dueReason)
+		}
+		return nil
+	}
+	if len(p) > 0 {
+		r.midLine = p[len(p)-1] != '\n'
+	}
+	if due {
+		r.rotatePending = true
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"testing"
+)
+
+func TestMultiline(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt", WithMultiline(regexp.MustCompile(`^\d\d:\d\d `)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(10)
+	for _, s := range []string{
+		"10:00 panic: boom\n",
+		"\n",
+		"goroutine 1 [running]:\n",
+		"main.main()\n\t/src/main.go:10\n",
+		"10:01 restarted\n",
+	} {
+		if _, err := x.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	x.Close()
+
+	expected := "10:00 panic: boom\n\ngoroutine 1 [running]:\nmain.main()\n\t/src/main.go:10\n"
+	if b, _ := ioutil.ReadFile(path.Join(root, "mt_1")); string(b) != expected {
+		t.Errorf("archive: %q, expected %q", b, expected)
+	}
+	if b, _ := ioutil.ReadFile(path.Join(root, fileDefault)); string(b) != "10:01 restarted\n" {
+		t.Errorf("current: %q", b)
+	}
+}
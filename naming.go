@@ -0,0 +1,187 @@
+package rotate
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const namePatternDefault = "{prefix}_{n}"
+
+// nameToken is a placeholder that can be used in a name pattern.
+type nameToken struct {
+	// expand returns the token's value for an archive rotated
+	// with counter at t.
+	expand func(r *Writer, counter int, t time.Time) string
+
+	// re matches the token's values.
+	re string
+}
+
+var nameTokens = map[string]nameToken{
+	"prefix": {
+		expand: func(r *Writer, counter int, t time.Time) string { return r.prefix },
+	},
+	"n": {
+		expand: func(r *Writer, counter int, t time.Time) string { return strconv.Itoa(counter) },
+		re:     `[0-9]+`,
+	},
+	"date": {
+		expand: func(r *Writer, counter int, t time.Time) string { return t.Format("2006-01-02") },
+		re:     `[0-9]{4}-[0-9]{2}-[0-9]{2}`,
+	},
+	"time": {
+		expand: func(r *Writer, counter int, t time.Time) string { return t.Format("150405") },
+		re:     `[0-9]{6}`,
+	},
+	"host": {
+		expand: func(r *Writer, counter int, t time.Time) string { return hostname() },
+	},
+	"pid": {
+		expand: func(r *Writer, counter int, t time.Time) string { return strconv.Itoa(os.Getpid()) },
+		re:     `[0-9]+`,
+	},
+}
+
+var (
+	hostOnce sync.Once
+	hostName string
+)
+
+// hostname returns the host name made safe for use in a file name.
+func hostname() string {
+	hostOnce.Do(func() {
+		h, err := os.Hostname()
+		if err != nil || h == "" {
+			h = "unknown"
+		}
+		hostName = strings.Map(func(c rune) rune {
+			if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '-' || c == '_' {
+				return c
+			}
+			return '-'
+		}, h)
+	})
+	return hostName
+}
+
+var tokenRE = regexp.MustCompile(`\{[a-z]+\}`)
+
+// SetNamePattern sets the pattern used to name archives.  It may
+// contain these tokens:
+//
+//	{prefix}  the prefix given to New
+//	{n}       the rotation counter
+//	{date}    the date of the rotation, as 2006-01-02
+//	{time}    the time of the rotation, as 150405
+//	{host}    the host name
+//	{pid}     the process ID
+//
+// The default is "{prefix}_{n}".  Patterns such as "{prefix}.{n}.log"
+// or "{prefix}.{date}.log" keep an extension that log viewers and
+// shippers recognize.  With live compression, the compressor's
+// extension is appended to the name.
+//
+// Archives are ordered by {n}, or by modification time if the
+// pattern does not contain it.  Such a pattern must make every name
+// unique, for example with {date} and {time}: a rotation to a name
+// that exists fails and the current file is kept.
+//
+// {host} and {pid} keep the names of several instances writing to a
+// shared directory apart.  Only archives with this host's name are
+// listed and cleaned, while archives with any process ID are, so
+// those of earlier runs are still cleaned.
+func (r *Writer) SetNamePattern(pattern string) error {
+	nameRE, err := r.compileNamePattern(pattern)
+	if err != nil {
+		return err
+	}
+	r.Lock()
+	defer r.Unlock()
+	lay := *r.layout()
+	lay.namePattern = pattern
+	lay.nameRE = nameRE
+	r.lay.Store(&lay)
+	return nil
+}
+
+// compileNamePattern returns a regexp that matches the names pattern
+// produces for this Writer.
+func (r *Writer) compileNamePattern(pattern string) (*regexp.Regexp, error) {
+	if strings.ContainsAny(pattern, `/\`) {
+		return nil, errors.New("rotate: name pattern must not contain a path separator")
+	}
+	var re strings.Builder
+	re.WriteString("^")
+	last, variable, counter := 0, false, false
+	for _, loc := range tokenRE.FindAllStringIndex(pattern, -1) {
+		name := pattern[loc[0]+1 : loc[1]-1]
+		tok, ok := nameTokens[name]
+		if !ok {
+			return nil, errors.New("rotate: unknown token in name pattern: " + pattern[loc[0]:loc[1]])
+		}
+		re.WriteString(regexp.QuoteMeta(pattern[last:loc[0]]))
+		switch {
+		case name == "prefix":
+			re.WriteString(regexp.QuoteMeta(r.prefix))
+		case name == "host":
+			re.WriteString(regexp.QuoteMeta(hostname()))
+		case name == "n" && !counter:
+			re.WriteString(`(?P<n>` + tok.re + `)`)
+			counter = true
+		default:
+			re.WriteString(tok.re)
+		}
+		if name != "prefix" && name != "host" {
+			variable = true
+		}
+		last = loc[1]
+	}
+	if !variable {
+		return nil, errors.New("rotate: name pattern must contain {n}, {date} or {time}")
+	}
+	re.WriteString(regexp.QuoteMeta(pattern[last:]))
+	var exts []string
+	for _, c := range compressors {
+		exts = append(exts, regexp.QuoteMeta(c.Ext()))
+	}
+	re.WriteString(`(?:` + strings.Join(exts, "|") + `)?(?:` + regexp.QuoteMeta(encExt) + `)?$`)
+
+	return regexp.Compile(re.String())
+}
+
+// archiveName returns the name for the archive rotated with counter
+// at t.
+func (r *Writer) archiveName(counter int, t time.Time) string {
+	name := r.expandName(counter, t)
+	if r.live != nil {
+		name += r.live.Ext()
+	}
+	return name
+}
+
+// expandName expands the name pattern for counter and t.
+func (r *Writer) expandName(counter int, t time.Time) string {
+	return tokenRE.ReplaceAllStringFunc(r.layout().namePattern, func(tok string) string {
+		return nameTokens[tok[1:len(tok)-1]].expand(r, counter, t)
+	})
+}
+
+// parseName reports whether name is an archive of this Writer and
+// returns its counter, which is zero if the pattern does not have
+// one.
+func (r *Writer) parseName(name string) (counter int, ok bool) {
+	nameRE := r.layout().nameRE
+	m := nameRE.FindStringSubmatch(name)
+	if m == nil {
+		return 0, false
+	}
+	if i := nameRE.SubexpIndex("n"); i > 0 {
+		counter, _ = strconv.Atoi(m[i])
+	}
+	return counter, true
+}
@@ -0,0 +1,117 @@
+package rotate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestNamePattern(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []string{"{prefix}", "{prefix}_{x}", "a/{n}"} {
+		if err := x.SetNamePattern(bad); err == nil {
+			t.Errorf("SetNamePattern(%q) succeeded", bad)
+		}
+	}
+	if err := x.SetNamePattern("{prefix}.{n}.log"); err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(5)
+	for i := 0; i < 11; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 10 || archives[0].Name != "mt.2.log" || archives[9].Name != "mt.11.log" {
+		t.Errorf("archives: %+v", archives)
+	}
+}
+
+func TestNamePatternCollision(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := x.SetNamePattern("{prefix}.{date}.log"); err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(5)
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.Write([]byte("again\n")); err == nil {
+		t.Fatal("expected an error rotating to an existing name")
+	}
+	// the current file is still usable
+	if _, err := x.Write([]byte("more\n")); err == nil {
+		t.Fatal("expected an error rotating to an existing name")
+	}
+	data, err := ioutil.ReadFile(path.Join(root, fileDefault))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "again\nmore\n" {
+		t.Errorf("current: %q, expected %q", data, "again\nmore\n")
+	}
+	if _, err := os.Stat(path.Join(root, "mt."+time.Now().Format("2006-01-02")+".log")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNamePatternHostPID(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	// archives of another host and of an earlier run on this one
+	for _, n := range []string{"mt.otherhost.1.1.log", "mt." + hostname() + ".1.1.log"} {
+		if err := ioutil.WriteFile(path.Join(root, n), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := x.SetNamePattern("{prefix}.{host}.{pid}.{n}.log"); err != nil {
+		t.Fatal(err)
+	}
+	x.SetCounter(2)
+	x.SetMax(1)
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := fmt.Sprintf("mt.%s.%d.2.log", hostname(), os.Getpid())
+	if len(archives) != 2 || archives[1].Name != expected {
+		t.Errorf("archives: %+v, expected the earlier run's and %s", archives, expected)
+	}
+}
@@ -0,0 +1,78 @@
+package rotate
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+)
+
+// staleRetries is how many times an operation that fails with
+// ESTALE is retried.
+const staleRetries = 5
+
+// nfsTempRE matches the suffix of the temporary names used by
+// NFS-safe rotation.
+var nfsTempRE = regexp.MustCompile(`\.nfs\d+-\d+$`)
+
+// SetNFSSafe sets whether rotation takes care to be reliable when the
+// root is on NFS or another network filesystem.  The current file is
+// first renamed to a name unique to the process, then linked to the
+// archive name, which fails rather than silently replacing an
+// archive that another client created, and the archive's size is
+// checked against the current file's.  Operations that fail with
+// ESTALE, a stale file handle, are retried.
+func (r *Writer) SetNFSSafe(on bool) {
+	r.Lock()
+	r.nfsSafe = on
+	r.Unlock()
+}
+
+// moveFileNFS moves src to dst through a unique temporary name,
+// without ever replacing dst.
+func moveFileNFS(src, dst string) error {
+	tmp := fmt.Sprintf("%s.nfs%d-%d.tmp", dst, os.Getpid(), time.Now().UnixNano())
+	if err := retryStale(func() error { return moveFile(src, tmp) }); err != nil {
+		return err
+	}
+	err := retryStale(func() error { return os.Link(tmp, dst) })
+	if os.IsExist(err) {
+		retryStale(func() error { return os.Rename(tmp, src) })
+		return fmt.Errorf("%w: %s", ErrNameCollision, dst)
+	}
+	if err != nil {
+		// the filesystem may not support hard links
+		return retryStale(func() error { return os.Rename(tmp, dst) })
+	}
+	return retryStale(func() error { return os.Remove(tmp) })
+}
+
+// verifySize checks that the file at p is size bytes long.
+func verifySize(p string, size int64) error {
+	var fi os.FileInfo
+	err := retryStale(func() (err error) {
+		fi, err = os.Stat(p)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if fi.Size() != size {
+		return fmt.Errorf("%s is %d bytes, expected %d", p, fi.Size(), size)
+	}
+	return nil
+}
+
+// retryStale calls fn until it does not fail with ESTALE, up to
+// staleRetries times, backing off between tries.
+func retryStale(fn func() error) error {
+	delay := 10 * time.Millisecond
+	for i := 0; ; i++ {
+		err := fn()
+		if i == staleRetries || !isStale(err) {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
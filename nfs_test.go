@@ -0,0 +1,50 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestNFSSafe(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	// left by a rotation interrupted before the link
+	if err := ioutil.WriteFile(path.Join(root, "mt_5.nfs123-456.tmp"), []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(path.Join(root, "mt_5")); string(b) != "old\n" {
+		t.Errorf("recovered mt_5: %q", b)
+	}
+	if c := x.GetCounter(); c != 6 {
+		t.Errorf("counter: %d, expected 6", c)
+	}
+
+	x.SetNFSSafe(true)
+	x.SetMax(1)
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(path.Join(root, "mt_6")); string(b) != "hello\n" {
+		t.Errorf("mt_6: %q", b)
+	}
+	if err := x.LastError(); err != nil {
+		t.Errorf("verification: %v", err)
+	}
+	names, _ := ioutil.ReadDir(root)
+	if len(names) != 3 {
+		t.Errorf("%d files, expected mt_5, mt_6 and the current file without temporary names", len(names))
+	}
+}
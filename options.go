@@ -0,0 +1,128 @@
+package rotate
+
+import "time"
+
+// Options are the settings of a Writer that can be changed while it
+// is in use with Update.
+type Options struct {
+	// Max is the size in bytes at which the current file is
+	// rotated.
+	Max int
+
+	// Keep is the number of archives to keep, or KeepAll.
+	Keep int
+
+	// MaxAge, if not zero, is how long archives are kept after
+	// they were last written, as with SetMaxAge.
+	MaxAge time.Duration
+
+	// Compression, if not nil, is used to compress the current
+	// file as it is written, as with WithLiveCompression.  A
+	// change takes effect when the next current file is opened.
+	Compression Compressor
+
+	// NamePattern is the pattern for archive names, as given to
+	// SetNamePattern.
+	NamePattern string
+
+	// DatedDirs places archives in dated subdirectories, as with
+	// SetDatedDirs.
+	DatedDirs bool
+
+	// ArchiveDir is the directory archives are moved to, as with
+	// SetArchiveDir.  Empty means root.
+	ArchiveDir string
+}
+
+// DefaultOptions returns the settings of a Writer created without
+// options.
+func DefaultOptions() Options {
+	return Options{Max: maxDefault, Keep: keepDefault, NamePattern: namePatternDefault}
+}
+
+// WithOptions makes New start the Writer with the settings o, which
+// should be based on DefaultOptions.  Unlike calling Update after
+// New, the settings are already in place when New looks for files
+// left in the root and archive directories by an earlier run.
+func WithOptions(o Options) Option {
+	return func(r *Writer) {
+		var err error
+		if o.NamePattern, err = r.bucketPattern(o.NamePattern); err != nil {
+			r.optErr = err
+			return
+		}
+		nameRE, err := r.compileNamePattern(o.NamePattern)
+		if err != nil {
+			r.optErr = err
+			return
+		}
+		r.max = o.Max
+		r.keep = o.Keep
+		r.maxAge = o.MaxAge
+		r.live = o.Compression
+		r.liveNext = o.Compression
+		lay := *r.layout()
+		lay.archDir = o.ArchiveDir
+		lay.dated = o.DatedDirs
+		lay.namePattern = o.NamePattern
+		lay.nameRE = nameRE
+		r.lay.Store(&lay)
+	}
+}
+
+// Options returns the Writer's current settings.
+func (r *Writer) Options() Options {
+	r.Lock()
+	defer r.Unlock()
+	lay := r.layout()
+	return Options{
+		Max:         r.max,
+		Keep:        r.keep,
+		MaxAge:      r.maxAge,
+		Compression: r.liveNext,
+		NamePattern: lay.namePattern,
+		DatedDirs:   lay.dated,
+		ArchiveDir:  lay.archDir,
+	}
+}
+
+// Update atomically replaces the Writer's settings with o, so a
+// long running program can change its log policy without restarting.
+// To change a single setting, modify the result of Options:
+//
+//	o := w.Options()
+//	o.Keep = 20
+//	err := w.Update(o)
+//
+// If the current file is already larger than the new maximum it is
+// rotated, and archives beyond the new limit are deleted right away.
+func (r *Writer) Update(o Options) error {
+	var err error
+	if o.NamePattern, err = r.bucketPattern(o.NamePattern); err != nil {
+		return err
+	}
+	nameRE, err := r.compileNamePattern(o.NamePattern)
+	if err != nil {
+		return err
+	}
+	r.Lock()
+	defer r.Unlock()
+	r.max = o.Max
+	r.keep = o.Keep
+	r.maxAge = o.MaxAge
+	r.liveNext = o.Compression
+	lay := *r.layout()
+	lay.archDir = o.ArchiveDir
+	lay.dated = o.DatedDirs
+	lay.namePattern = o.NamePattern
+	lay.nameRE = nameRE
+	r.lay.Store(&lay)
+
+	if r.trigger == nil && r.size > 0 && r.size >= r.max {
+		return r.rotate(ReasonSize)
+	}
+	if err := r.clean(); err != nil {
+		return err
+	}
+	return r.writeManifest()
+}
@@ -0,0 +1,87 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	o := x.Options()
+	o.Max = 5
+	o.Compression = Gzip
+	o.NamePattern = "{prefix}.{n}.log"
+	if err := x.Update(o); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path.Join(root, "mt.1.log")); err != nil {
+		t.Errorf("not rotated on update: %v", err)
+	}
+	if _, err := x.Write([]byte("world\n")); err != nil {
+		t.Fatal(err)
+	}
+	if s := readGzip(t, path.Join(root, "mt.2.log.gz")); s != "world\n" {
+		t.Errorf("mt.2.log.gz: %q, expected %q", s, "world\n")
+	}
+
+	o.Keep = 1
+	if err := x.Update(o); err != nil {
+		t.Fatal(err)
+	}
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 1 || archives[0].Name != "mt.2.log.gz" {
+		t.Errorf("archives: %+v", archives)
+	}
+	if got := x.Options(); got != o {
+		t.Errorf("options: %+v, expected %+v", got, o)
+	}
+}
+
+func TestOptionsKeepLayout(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	x.SetSyncDirs(true)
+	x.SetHold(true)
+	x.SetReadOnlyArchives(true)
+
+	o := x.Options()
+	o.DatedDirs = true
+	WithOptions(o)(x)
+	if lay := x.layout(); !lay.syncDirs || !lay.hold || !lay.readOnly || !lay.dated {
+		t.Errorf("layout after WithOptions: %+v", lay)
+	}
+	o.DatedDirs = false
+	if err := x.Update(o); err != nil {
+		t.Fatal(err)
+	}
+	if lay := x.layout(); !lay.syncDirs || !lay.hold || !lay.readOnly || lay.dated {
+		t.Errorf("layout after Update: %+v", lay)
+	}
+}
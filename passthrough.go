@@ -0,0 +1,39 @@
+package rotate
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"time"
+)
+
+// openPassthrough opens the current file as it is if it is a
+// character device or a pipe, such as /dev/stdout in a container,
+// and reports whether it is one.  Such a file is never rotated,
+// renamed or cleaned up after, so the same code can log to files on
+// one machine and to stdout in a container, by pointing root and the
+// file name at /dev and stdout.  The lock must be held, unless the
+// Writer is still being created.
+func (r *Writer) openPassthrough() (bool, error) {
+	cp := path.Join(r.root, r.currentName())
+	fi, err := os.Stat(cp)
+	if err != nil || fi.Mode()&(os.ModeCharDevice|os.ModeNamedPipe) == 0 {
+		return false, nil
+	}
+	f, err := os.OpenFile(cp, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return true, fmt.Errorf("rotate: open %s: %w", cp, err)
+	}
+	r.passthrough = true
+	r.current = f
+	r.out = f
+	if r.bufSize > 0 {
+		r.buf = bufio.NewWriterSize(r.out, r.bufSize)
+		r.out = r.buf
+	}
+	r.opened = time.Now()
+	r.curPath.Store(cp)
+	r.logf("writing to %s without rotation", cp)
+	return true, nil
+}
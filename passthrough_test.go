@@ -0,0 +1,54 @@
+//go:build unix && !solaris && !aix
+
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"syscall"
+	"testing"
+)
+
+func TestPassthrough(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	fifo := path.Join(root, "pipe")
+	if err := syscall.Mkfifo(fifo, 0600); err != nil {
+		t.Fatal(err)
+	}
+	rd, err := os.OpenFile(fifo, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rd.Close()
+
+	x, err := New(root, "mt", WithFileName("pipe"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(4)
+	for i := 0; i < 3; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 64)
+	n, err := rd.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b[:n]) != "hello\nhello\nhello\n" {
+		t.Errorf("got %q", b[:n])
+	}
+	if _, err := os.Stat(path.Join(root, "mt_1")); !os.IsNotExist(err) {
+		t.Errorf("pipe was rotated: %v", err)
+	}
+}
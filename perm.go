@@ -0,0 +1,69 @@
+package rotate
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WithExactPerm makes the Writer set the permissions of the files
+// and directories it creates to exactly those configured with
+// WithFilePerm and WithRootPerm.  Otherwise the process umask is
+// applied, so a FilePerm of 0666 gives 0644 files under the usual
+// umask of 022 but 0600 files under 077.  Existing directories,
+// such as a root shared with other programs, are left alone.
+func WithExactPerm() Option {
+	return func(r *Writer) {
+		r.exactPerm = true
+	}
+}
+
+// mkdirAll creates dir and any missing parents with the directory
+// permissions, applying them exactly with WithExactPerm.
+func (r *Writer) mkdirAll(dir string) error {
+	if !r.exactPerm {
+		return os.MkdirAll(dir, r.rootPerm)
+	}
+	var created []string
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil || !os.IsNotExist(err) {
+			break
+		}
+		created = append(created, d)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	if err := os.MkdirAll(dir, r.rootPerm); err != nil {
+		return err
+	}
+	for _, d := range created {
+		if err := os.Chmod(d, r.rootPerm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fixPerm sets the permissions of the file at p, which the Writer
+// wrote, with WithExactPerm.
+func (r *Writer) fixPerm(p string) error {
+	if !r.exactPerm {
+		return nil
+	}
+	return os.Chmod(p, r.filePerm)
+}
+
+// fixFilePerm is fixPerm for the open file f.
+func (r *Writer) fixFilePerm(f *os.File) error {
+	if !r.exactPerm {
+		return nil
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Mode().Perm() == r.filePerm {
+		return nil
+	}
+	return f.Chmod(r.filePerm)
+}
@@ -0,0 +1,110 @@
+//go:build unix
+
+package rotate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"syscall"
+	"testing"
+)
+
+func TestExactPerm(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	defer syscall.Umask(syscall.Umask(077))
+	logs := path.Join(root, "logs", "app")
+	x, err := New(logs, "mt", WithExactPerm(), WithRootPerm(0755), WithFilePerm(0644))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetDatedDirs(true)
+	x.SetMax(1)
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	x.Close()
+	archives, err := x.Archives()
+	if err != nil || len(archives) != 1 {
+		t.Fatalf("archives: %v, %v", archives, err)
+	}
+	for _, tc := range []struct {
+		p    string
+		perm os.FileMode
+	}{
+		{path.Join(root, "logs"), 0755},
+		{logs, 0755},
+		{path.Dir(archives[0].Path), 0755},
+		{path.Dir(path.Dir(path.Dir(archives[0].Path))), 0755},
+		{path.Join(logs, fileDefault), 0644},
+		{archives[0].Path, 0644},
+	} {
+		fi, err := os.Stat(tc.p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != tc.perm {
+			t.Errorf("%s: %v, expected %v", tc.p, fi.Mode().Perm(), tc.perm)
+		}
+	}
+}
+
+func TestExactPermImport(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(path.Join(root, "a"), "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetSidecar(true)
+	x.SetMax(1)
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	x.Close()
+	var buf bytes.Buffer
+	if err := x.ExportBundle(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	defer syscall.Umask(syscall.Umask(077))
+	y, err := New(path.Join(root, "b"), "mt", WithExactPerm(), WithRootPerm(0755), WithFilePerm(0644))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer y.Close()
+	y.SetArchiveDir(path.Join(root, "old"))
+	if err := y.ImportBundle(&buf); err != nil {
+		t.Fatal(err)
+	}
+	archives, err := y.Archives()
+	if err != nil || len(archives) == 0 {
+		t.Fatalf("archives: %v, %v", archives, err)
+	}
+	for _, tc := range []struct {
+		p    string
+		perm os.FileMode
+	}{
+		{path.Dir(archives[0].Path), 0755},
+		{archives[0].Path, 0644},
+		{archives[0].Path + metaSuffix, 0644},
+	} {
+		fi, err := os.Stat(tc.p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != tc.perm {
+			t.Errorf("%s: %v, expected %v", tc.p, fi.Mode().Perm(), tc.perm)
+		}
+	}
+}
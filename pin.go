@@ -0,0 +1,139 @@
+package rotate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+)
+
+// pinsSuffix is appended to the prefix to name the file in the root
+// directory that lists the pinned archives.
+const pinsSuffix = ".pins.json"
+
+// Pin excludes the archive with counter from retention, so logs
+// spanning an incident do not age out during the investigation.
+// Pinned archives are not deleted by the keep limit, the maximum
+// age, SetMinFree or a Coordinator, and do not count toward the keep
+// limit, until they are unpinned.  The counter of the current file,
+// from GetCounter, may be pinned before it is rotated.
+//
+// Pins are kept in the root directory as <prefix>.pins.json, so they
+// survive restarts.
+func (r *Writer) Pin(counter int) error {
+	return r.setPinned(counter, true)
+}
+
+// Unpin releases an archive pinned by Pin.  It is deleted at the next
+// rotation if retention no longer keeps it.
+func (r *Writer) Unpin(counter int) error {
+	return r.setPinned(counter, false)
+}
+
+// Pinned returns the counters of the pinned archives, in order.
+func (r *Writer) Pinned() ([]int, error) {
+	r.pinMu.Lock()
+	defer r.pinMu.Unlock()
+	if err := r.loadPins(); err != nil {
+		return nil, err
+	}
+	return r.pinList(), nil
+}
+
+func (r *Writer) setPinned(counter int, on bool) error {
+	if counter < 1 {
+		return fmt.Errorf("rotate: pin %d: archives are numbered from 1", counter)
+	}
+	r.pinMu.Lock()
+	defer r.pinMu.Unlock()
+	if err := r.loadPins(); err != nil {
+		return err
+	}
+	if r.pins[counter] == on {
+		return nil
+	}
+	if on {
+		r.pins[counter] = true
+	} else {
+		delete(r.pins, counter)
+	}
+	if err := r.savePins(); err != nil {
+		return fmt.Errorf("rotate: save pins: %w", err)
+	}
+	return nil
+}
+
+// isPinned returns whether the archive a is pinned.  It does not
+// need the lock.
+func (r *Writer) isPinned(a ArchiveInfo) bool {
+	r.pinMu.Lock()
+	defer r.pinMu.Unlock()
+	if err := r.loadPins(); err != nil {
+		// keep everything rather than risk losing a pinned
+		// archive
+		r.logf("%v", err)
+		return true
+	}
+	return a.Counter > 0 && r.pins[a.Counter]
+}
+
+// pinsPath returns the path of the file listing the pinned archives.
+func (r *Writer) pinsPath() string {
+	return path.Join(r.layout().root, r.prefix+pinsSuffix)
+}
+
+// loadPins reads the pinned archives, if they have not been read
+// yet.  pinMu must be held.
+func (r *Writer) loadPins() error {
+	if r.pins != nil {
+		return nil
+	}
+	pins := make(map[int]bool)
+	data, err := ioutil.ReadFile(r.pinsPath())
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("rotate: read pins: %w", err)
+	}
+	if err == nil {
+		var counters []int
+		if err := json.Unmarshal(data, &counters); err != nil {
+			return fmt.Errorf("rotate: read pins: %w", err)
+		}
+		for _, c := range counters {
+			pins[c] = true
+		}
+	}
+	r.pins = pins
+	return nil
+}
+
+// pinList returns the pinned counters in order.  pinMu must be held.
+func (r *Writer) pinList() []int {
+	counters := make([]int, 0, len(r.pins))
+	for c := range r.pins {
+		counters = append(counters, c)
+	}
+	sort.Ints(counters)
+	return counters
+}
+
+// savePins writes the pinned archives, removing the file when there
+// are none.  pinMu must be held.
+func (r *Writer) savePins() error {
+	p := r.pinsPath()
+	if len(r.pins) == 0 {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(r.pinList())
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(p, append(data, '\n'), r.filePerm); err != nil {
+		return err
+	}
+	return r.fixPerm(p)
+}
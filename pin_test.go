@@ -0,0 +1,76 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestPin(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(6)
+	x.SetKeep(1)
+	if err := x.Pin(0); err == nil {
+		t.Error("pinned counter 0")
+	}
+	// the current file, pinned before it is rotated
+	if err := x.Pin(x.GetCounter()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names := func() []string {
+		archives, err := x.Archives()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, a := range archives {
+			names = append(names, a.Name)
+		}
+		return names
+	}
+	if got := names(); !reflect.DeepEqual(got, []string{"mt_1", "mt_4"}) {
+		t.Errorf("archives: %q", got)
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// pins outlive the Writer
+	x, err = New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	x.SetMax(6)
+	x.SetKeep(1)
+	if pinned, err := x.Pinned(); err != nil || !reflect.DeepEqual(pinned, []int{1}) {
+		t.Errorf("pinned: %v %v", pinned, err)
+	}
+	if err := x.Unpin(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(x.pinsPath()); !os.IsNotExist(err) {
+		t.Errorf("pins file: %v", err)
+	}
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := names(); !reflect.DeepEqual(got, []string{"mt_5"}) {
+		t.Errorf("archives after unpinning: %q", got)
+	}
+}
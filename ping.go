@@ -0,0 +1,84 @@
+package rotate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Ping checks that the Writer can still log, for readiness and
+// liveness probes in services where losing logs is a hard failure:
+// the current file must be open, it must be possible to open it for
+// writing, no data may be waiting in memory because of failed
+// writes, and its filesystem must have free space, at least as much
+// as SetMinFree asks for.  Ping does not write anything; see
+// PingWrite.
+func (r *Writer) Ping() error {
+	r.Lock()
+	defer r.Unlock()
+	return r.ping()
+}
+
+// PingWrite is like Ping, but also proves that the current file can
+// be written by appending a byte to it and truncating it back.  It
+// cannot do so with live compression, and returns an error then.
+func (r *Writer) PingWrite() error {
+	r.Lock()
+	defer r.Unlock()
+	if err := r.ping(); err != nil || r.unopened || r.passthrough || r.discard {
+		return err
+	}
+	if r.enc != nil {
+		return errors.New("rotate: ping: cannot write to a compressed file")
+	}
+	// other processes must not write in between
+	if err := r.lockShared(); err != nil {
+		return err
+	}
+	defer r.unlockShared()
+	if err := r.flush(); err != nil {
+		return err
+	}
+	fi, err := r.current.Stat()
+	if err != nil {
+		return fmt.Errorf("rotate: ping: %w", err)
+	}
+	if _, err := r.current.Write([]byte{'\n'}); err != nil {
+		return fmt.Errorf("rotate: ping: write: %w", err)
+	}
+	if err := r.current.Truncate(fi.Size()); err != nil {
+		return fmt.Errorf("rotate: ping: truncate: %w", err)
+	}
+	return nil
+}
+
+// ping does the checks of Ping.  The lock must be held.
+func (r *Writer) ping() error {
+	if r.unopened {
+		// nothing to check until the first write
+		return nil
+	}
+	if r.current == nil {
+		return ErrClosed
+	}
+	if r.discard {
+		return nil
+	}
+	if r.ring != nil && len(r.ring.buf) > 0 {
+		return fmt.Errorf("rotate: ping: %d bytes held in memory after failed writes", len(r.ring.buf))
+	}
+	cp := r.currentPath()
+	f, err := os.OpenFile(cp, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return fmt.Errorf("rotate: ping: %w", err)
+	}
+	f.Close()
+	free, _, err := diskFree(r.layout().root)
+	if err != nil {
+		return fmt.Errorf("rotate: ping: check free space: %w", err)
+	}
+	if free == 0 || (free > 0 && free < r.minFreeBytes) {
+		return fmt.Errorf("rotate: ping: only %d bytes free for %s", free, cp)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Ping(); err != nil {
+		t.Error(err)
+	}
+	if err := x.PingWrite(); err != nil {
+		t.Error(err)
+	}
+	if b, _ := ioutil.ReadFile(path.Join(root, fileDefault)); string(b) != "hello\n" {
+		t.Errorf("file after PingWrite: %q", b)
+	}
+
+	defer func() { diskFree = statDisk }()
+	diskFree = func(string) (int64, int64, error) { return 0, 0, nil }
+	if err := x.Ping(); err == nil {
+		t.Error("ping with a full disk succeeded")
+	}
+	x.Close()
+	if err := x.Ping(); err != ErrClosed {
+		t.Errorf("ping after close: %v", err)
+	}
+}
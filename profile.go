@@ -0,0 +1,29 @@
+package rotate
+
+import (
+	"context"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// goLabeled runs fn in a new goroutine with profile labels, "rotate"
+// set to op and, if not empty, "prefix" to the Writer's prefix, so
+// CPU and goroutine profiles attribute its work to this package.
+// Goroutines it starts inherit the labels.
+func goLabeled(op, prefix string, fn func()) {
+	labels := []string{"rotate", op}
+	if prefix != "" {
+		labels = append(labels, "prefix", prefix)
+	}
+	go pprof.Do(context.Background(), pprof.Labels(labels...), func(context.Context) {
+		fn()
+	})
+}
+
+// region runs fn in a runtime/trace region named "rotate." + op, so
+// execution traces show the I/O stalls it causes.  It costs next to
+// nothing when no trace is being taken.  Work done in the caller's
+// goroutine gets no profile labels, as those belong to the caller.
+func region(op string, fn func()) {
+	trace.WithRegion(context.Background(), "rotate."+op, fn)
+}
@@ -0,0 +1,31 @@
+package rotate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"runtime/pprof"
+	"testing"
+)
+
+func TestProfileLabels(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt", WithAsync(AsyncOptions{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	// the goroutine may not have started yet
+	waitFor(t, "labeled goroutine", func() bool {
+		var buf bytes.Buffer
+		if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+			t.Fatal(err)
+		}
+		return bytes.Contains(buf.Bytes(), []byte(`"rotate":"async"`))
+	})
+}
@@ -0,0 +1,68 @@
+package rotate
+
+import (
+	"fmt"
+	"os"
+)
+
+// archivePerm is the mode of read-only archives.
+const archivePerm = 0444
+
+// SetReadOnlyArchives sets whether archives are made read-only,
+// mode 0444, once they are rotated, so they cannot be modified by
+// accident before they are shipped or audited.  Retention still
+// deletes them.
+func (r *Writer) SetReadOnlyArchives(on bool) {
+	r.Lock()
+	defer r.Unlock()
+	lay := *r.layout()
+	lay.readOnly = on
+	r.lay.Store(&lay)
+}
+
+// SetImmutableArchives sets whether archives are made read-only and,
+// on Linux, also given the immutable attribute, which not even root
+// can write through without clearing it first.  Setting the
+// attribute needs CAP_LINUX_IMMUTABLE; it is best effort, so a
+// failure is only logged.  The attribute is cleared before retention
+// deletes an archive.
+func (r *Writer) SetImmutableArchives(on bool) {
+	r.Lock()
+	defer r.Unlock()
+	lay := *r.layout()
+	lay.readOnly = on
+	lay.immutable = on
+	r.lay.Store(&lay)
+}
+
+// protectArchive makes the archive at p read-only, and immutable if
+// set.  It does not need the lock.
+func (r *Writer) protectArchive(p string) error {
+	lay := r.layout()
+	if !lay.readOnly {
+		return nil
+	}
+	if err := os.Chmod(p, archivePerm); err != nil {
+		return fmt.Errorf("rotate: make %s read-only: %w", p, err)
+	}
+	if lay.immutable {
+		if err := setImmutable(p, true); err != nil {
+			r.logf("could not make %s immutable: %v", p, err)
+		}
+	}
+	return nil
+}
+
+// unprotectArchive undoes protectArchive so the archive at p can be
+// deleted on every platform.  It does not need the lock.
+func (r *Writer) unprotectArchive(p string) {
+	lay := r.layout()
+	if !lay.readOnly {
+		return
+	}
+	if lay.immutable {
+		setImmutable(p, false)
+	}
+	// Windows does not delete read-only files
+	os.Chmod(p, r.filePerm)
+}
@@ -0,0 +1,53 @@
+//go:build linux && (amd64 || arm64 || riscv64 || loong64 || 386 || arm)
+
+package rotate
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// The ioctls of chattr, for the architectures that use the generic
+// ioctl number encoding.
+const (
+	fsIocGetflags = 2<<30 | unsafe.Sizeof(uintptr(0))<<16 | 'f'<<8 | 1
+	fsIocSetflags = 1<<30 | unsafe.Sizeof(uintptr(0))<<16 | 'f'<<8 | 2
+	fsImmutableFl = 0x10
+)
+
+// setImmutable sets or clears the immutable attribute of the file at
+// p.
+func setImmutable(p string, on bool) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	err = rc.Control(func(fd uintptr) {
+		var flags int32
+		if _, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, fsIocGetflags, uintptr(unsafe.Pointer(&flags))); errno != 0 {
+			return
+		}
+		if on {
+			flags |= fsImmutableFl
+		} else if flags&fsImmutableFl != 0 {
+			flags &^= fsImmutableFl
+		} else {
+			return
+		}
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, fsIocSetflags, uintptr(unsafe.Pointer(&flags)))
+	})
+	if err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
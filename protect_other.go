@@ -0,0 +1,9 @@
+//go:build !linux || !(amd64 || arm64 || riscv64 || loong64 || 386 || arm)
+
+package rotate
+
+import "errors"
+
+func setImmutable(p string, on bool) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,44 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestReadOnlyArchives(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	x.SetReadOnlyArchives(true)
+	x.SetMax(1)
+	x.SetKeep(1)
+	for i := 0; i < 2; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 1 {
+		t.Fatalf("archives: %d, expected retention to delete a read-only one", len(archives))
+	}
+	fi, err := os.Stat(archives[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && fi.Mode().Perm() != archivePerm {
+		t.Errorf("archive mode: %v, expected %v", fi.Mode().Perm(), os.FileMode(archivePerm))
+	}
+}
@@ -0,0 +1,163 @@
+package rotate
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// PruneOptions controls Prune.
+type PruneOptions struct {
+	// Options describe the archives as the Writer that made them
+	// was configured: Keep, NamePattern, DatedDirs and ArchiveDir
+	// are used.  Start from DefaultOptions.
+	Options Options
+
+	// Compress, if not nil, compresses the archives that are kept
+	// and are not compressed yet.
+	Compress Compressor
+
+	// DryRun makes Prune only report what it would do.
+	DryRun bool
+}
+
+// PruneResult lists what Prune did, oldest first.
+type PruneResult struct {
+	Deleted    []ArchiveInfo
+	Compressed []ArchiveInfo
+}
+
+// Prune applies retention and compression to the archives of prefix
+// in root without a Writer, so a directory can be cleaned up offline,
+// such as one written by an older version or by another program
+// that names its files the same way.  It must not be used on a
+// directory a Writer is using.
+func Prune(ctx context.Context, root, prefix string, opts PruneOptions) (PruneResult, error) {
+	var res PruneResult
+	r, err := newWriter(root, prefix)
+	if err != nil {
+		return res, err
+	}
+	WithOptions(opts.Options)(r)
+	if r.optErr != nil {
+		return res, r.optErr
+	}
+	if res.Deleted, err = r.cleanArchives(ctx, r.keep, r.maxAge, opts.DryRun); err != nil {
+		return res, err
+	}
+	if opts.Compress == nil {
+		return res, nil
+	}
+
+	archives, err := r.archives()
+	if err != nil {
+		return res, fmt.Errorf("rotate: list archives: %w", err)
+	}
+	// a dry run leaves the archives it would delete in place
+	deleted := make(map[string]bool, len(res.Deleted))
+	for _, a := range res.Deleted {
+		deleted[a.Path] = true
+	}
+	for _, a := range archives {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		if deleted[a.Path] || compressorFor(a.Name) != nil || isEncrypted(a.Name) {
+			continue
+		}
+		if !opts.DryRun {
+			region("compress", func() { a, err = compressArchive(a, opts.Compress, r.filePerm) })
+			if err != nil {
+				return res, fmt.Errorf("rotate: compress archive: %w", err)
+			}
+			r.logf("compressed %s", a.Path)
+			r.emit(Event{Kind: EventCompressed, Archive: a})
+		}
+		res.Compressed = append(res.Compressed, a)
+	}
+	return res, nil
+}
+
+// compressArchive replaces the archive a with a copy compressed by
+// c, and moves its metadata along.  It returns the new archive.
+func compressArchive(a ArchiveInfo, c Compressor, perm os.FileMode) (ArchiveInfo, error) {
+	src, err := os.Open(a.Path)
+	if err != nil {
+		return a, err
+	}
+	defer src.Close()
+	fi, err := src.Stat()
+	if err != nil {
+		return a, err
+	}
+
+	dst := a.Path + c.Ext()
+	tmp := dst + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return a, err
+	}
+	bw := bufio.NewWriter(f)
+	var ids []string
+	err = func() error {
+		enc, err := c.NewWriter(bw)
+		if err != nil {
+			return err
+		}
+		ids = keyIDs(enc)
+		if _, err := io.Copy(enc, src); err != nil {
+			return err
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		return f.Sync()
+	}()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		// the archive keeps its age for retention
+		err = os.Chtimes(tmp, time.Time{}, fi.ModTime())
+	}
+	if err == nil {
+		// best effort, as for a copy to an archive directory
+		copyXattrs(a.Path, tmp)
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return a, err
+	}
+
+	if meta := readMeta(a.Path); meta != nil {
+		meta.Compression = strings.TrimPrefix(c.Ext(), ".")
+		meta.KeyIDs = ids
+		data, err := json.MarshalIndent(meta, "", "\t")
+		if err != nil {
+			return a, err
+		}
+		if err := writeFileAtomic(dst+metaSuffix, append(data, '\n'), perm); err != nil {
+			return a, err
+		}
+		os.Remove(a.Path + metaSuffix)
+	}
+	if err := os.Remove(a.Path); err != nil {
+		return a, err
+	}
+
+	a.Name += c.Ext()
+	a.Path = dst
+	if fi, err := os.Stat(dst); err == nil {
+		a.Size = fi.Size()
+	}
+	return a, nil
+}
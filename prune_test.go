@@ -0,0 +1,87 @@
+package rotate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestPrune(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for _, name := range []string{"mt_1", "mt_2", "mt_3.gz", "mt_4", "other.log"} {
+		if err := ioutil.WriteFile(path.Join(root, name), []byte(name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	o := DefaultOptions()
+	o.Keep = 3
+	opts := PruneOptions{Options: o, Compress: Gzip, DryRun: true}
+	res, err := Prune(context.Background(), root, "mt", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Deleted) != 1 || res.Deleted[0].Name != "mt_1" || len(res.Compressed) != 2 {
+		t.Errorf("dry run: %+v", res)
+	}
+	if _, err := os.Stat(path.Join(root, "mt_1")); err != nil {
+		t.Errorf("dry run deleted mt_1: %v", err)
+	}
+
+	opts.DryRun = false
+	if res, err = Prune(context.Background(), root, "mt", opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Deleted) != 1 || len(res.Compressed) != 2 || res.Compressed[1].Name != "mt_4.gz" {
+		t.Errorf("prune: %+v", res)
+	}
+	names, _ := ioutil.ReadDir(root)
+	var got []string
+	for _, fi := range names {
+		got = append(got, fi.Name())
+	}
+	if len(got) != 4 || got[0] != "mt_2.gz" || got[1] != "mt_3.gz" || got[2] != "mt_4.gz" || got[3] != "other.log" {
+		t.Errorf("files after prune: %v", got)
+	}
+	if s := readGzip(t, path.Join(root, "mt_4.gz")); s != "mt_4\n" {
+		t.Errorf("mt_4.gz: %q", s)
+	}
+}
+
+func TestPruneDryRunMaxAge(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for _, name := range []string{"mt_1", "mt_2", "mt_3"} {
+		if err := ioutil.WriteFile(path.Join(root, name), []byte(name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(path.Join(root, "mt_2"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	o := DefaultOptions()
+	o.Keep = KeepAll
+	o.MaxAge = time.Hour
+	res, err := Prune(context.Background(), root, "mt", PruneOptions{Options: o, Compress: Gzip, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Deleted) != 1 || res.Deleted[0].Name != "mt_2" {
+		t.Errorf("deleted: %+v", res.Deleted)
+	}
+	if len(res.Compressed) != 2 || res.Compressed[0].Name != "mt_1" || res.Compressed[1].Name != "mt_3" {
+		t.Errorf("compressed: %+v", res.Compressed)
+	}
+}
@@ -0,0 +1,51 @@
+package rotate
+
+// RotationReason is why a current file was rotated.  It is recorded
+// in the archive's metadata and sent with EventRotated, to help
+// understand why a Writer rotates as often as it does.
+type RotationReason string
+
+const (
+	// ReasonSize is a file reaching the maximum size, or a
+	// SizeTrigger.
+	ReasonSize RotationReason = "size"
+
+	// ReasonTime is an IntervalTrigger.
+	ReasonTime RotationReason = "time"
+
+	// ReasonLines is a LinesTrigger.
+	ReasonLines RotationReason = "lines"
+
+	// ReasonTrigger is any other Trigger.
+	ReasonTrigger RotationReason = "trigger"
+
+	// ReasonManual is a call to Rotate.
+	ReasonManual RotationReason = "manual"
+
+	// ReasonSignal is RotateAll, usually called from a signal
+	// handler, or a value received by RotateOn.
+	ReasonSignal RotationReason = "signal"
+
+	// ReasonMarker is a write holding the marker set with
+	// SetRotateMarker.
+	ReasonMarker RotationReason = "marker"
+
+	// ReasonStart is the start behavior of New.
+	ReasonStart RotationReason = "start"
+
+	// ReasonBucket is the end of a period of WithTimeBuckets.
+	ReasonBucket RotationReason = "bucket"
+)
+
+// reasoner is a Trigger that knows why it fired.
+type reasoner interface {
+	reason() RotationReason
+}
+
+// triggerReason returns why t fired.
+func triggerReason(t Trigger) RotationReason {
+	if r, ok := t.(reasoner); ok {
+		return r.reason()
+	}
+	return ReasonTrigger
+}
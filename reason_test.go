@@ -0,0 +1,63 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRotationReason(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt", WithRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	x.SetSidecar(true)
+	x.SetRotateMarker([]byte("BOOT"))
+	events := x.Events()
+	x.SetMax(10)
+
+	write := func(s string) {
+		if _, err := x.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("hello world\n")
+	write("a\n")
+	if err := x.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	write("b\n")
+	write("BOOT\n")
+	write("c\n")
+	if err := RotateAll(); err != nil {
+		t.Fatal(err)
+	}
+	x.SetTrigger(AnyTrigger(LinesTrigger(100), IntervalTrigger(time.Nanosecond)))
+	write("d\n")
+
+	want := []RotationReason{ReasonSize, ReasonManual, ReasonMarker, ReasonSignal, ReasonTime}
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != len(want) {
+		t.Fatalf("%d archives, expected %d", len(archives), len(want))
+	}
+	for i, a := range archives {
+		if a.Meta == nil || a.Meta.Reason != want[i] {
+			t.Errorf("%s: meta %+v, expected reason %s", a.Name, a.Meta, want[i])
+		}
+		e := <-events
+		if e.Kind != EventRotated || e.Reason != want[i] {
+			t.Errorf("event %d: %v %s, expected rotated %s", i, e.Kind, e.Reason, want[i])
+		}
+	}
+}
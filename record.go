@@ -0,0 +1,47 @@
+package rotate
+
+// WriteRecord writes p as a single record, with a stronger contract
+// than Write: p is written contiguously to exactly one file.  If p
+// would take the current file past its maximum size, the file is
+// rotated before p is written rather than after, so unlike with
+// Write, no file grows past the maximum unless a single record is
+// larger.  With a Trigger, which cannot be asked in advance, the
+// file is rotated after p as with Write.
+//
+// p can still be split if the write fails partway and the rest goes
+// to a Fallback root.
+func (r *Writer) WriteRecord(p []byte) (int, error) {
+	if r.async != nil {
+		return r.async.enqueue(p, true)
+	}
+	r.Lock()
+	defer r.Unlock()
+	if err := r.ready(); err != nil {
+		return 0, err
+	}
+	return r.writeRecord(p)
+}
+
+// writeRecord writes p through the interceptors as one record.  The
+// lock must be held.
+func (r *Writer) writeRecord(p []byte) (int, error) {
+	b := r.intercept(p)
+	if len(b) == 0 {
+		return len(p), nil
+	}
+	// take the lock first so the size reflects other processes
+	if err := r.lockShared(); err != nil {
+		return 0, err
+	}
+	defer r.unlockShared()
+	r.checkSize()
+	if r.trigger == nil && r.size > 0 && r.size+len(b) > r.max {
+		if err := r.rotate(ReasonSize); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := r.write(b); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
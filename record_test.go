@@ -0,0 +1,45 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestWriteRecord(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for _, async := range []bool{false, true} {
+		dir := path.Join(root, "sync")
+		var opts []Option
+		if async {
+			dir = path.Join(root, "async")
+			opts = append(opts, WithAsync(AsyncOptions{}))
+		}
+		x, err := New(dir, "mt", opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		x.SetMax(10)
+		for _, s := range []string{"one\n", "two\n", "three\n", "four\n"} {
+			if _, err := x.WriteRecord([]byte(s)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		x.Close()
+		for name, expected := range map[string]string{
+			"mt_1":      "one\ntwo\n",
+			"mt_2":      "three\n",
+			fileDefault: "four\n",
+		} {
+			if b, _ := ioutil.ReadFile(path.Join(dir, name)); string(b) != expected {
+				t.Errorf("async %v: %s: %q, expected %q", async, name, b, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package rotate
+
+import (
+	"os"
+	"path"
+	"strings"
+)
+
+// recoverState cleans up after a crash in an earlier run.  It
+// removes the temporary files of archives that were being copied or
+// compressed, whose originals are still in place, gives archives
+// left under their temporary name by an NFS-safe rotation their
+// final name, and moves the counter past the existing archives so a
+// rotation never collides with them.
+func (r *Writer) recoverState() error {
+	dirs := []string{""}
+	if r.layout().dated {
+		dated, err := r.datedDirs()
+		if err != nil {
+			return err
+		}
+		dirs = append(dirs, dated...)
+	}
+	for _, dir := range dirs {
+		if err := r.removeTemp(path.Join(r.archiveRoot(), dir)); err != nil {
+			return err
+		}
+	}
+
+	return r.advanceCounter()
+}
+
+// advanceCounter moves the counter past the existing archives.
+func (r *Writer) advanceCounter() error {
+	archives, err := r.archives()
+	if err != nil {
+		return err
+	}
+	counter := r.counter
+	for _, a := range archives {
+		if a.Counter >= r.counter {
+			r.counter = a.Counter + 1
+		}
+	}
+	if r.counter != counter {
+		r.logf("counter moved from %d to %d past existing archives", counter, r.counter)
+	}
+	return nil
+}
+
+// removeTemp removes the temporary files of this Writer's archives
+// in dir.
+func (r *Writer) removeTemp(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer d.Close()
+	names, err := d.Readdirnames(-1)
+	if err != nil {
+		return err
+	}
+	for _, n := range names {
+		if !strings.HasSuffix(n, ".tmp") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimSuffix(n, ".tmp"), metaSuffix)
+		if nfs := nfsTempRE.FindStringIndex(name); nfs != nil {
+			// an NFS-safe rotation moved the current file here
+			// and did not get to link it to its archive name
+			name = name[:nfs[0]]
+			if _, ok := r.parseName(name); !ok {
+				continue
+			}
+			if _, err := os.Stat(path.Join(dir, name)); os.IsNotExist(err) {
+				if err := os.Rename(path.Join(dir, n), path.Join(dir, name)); err != nil {
+					return err
+				}
+				r.logf("completed the interrupted rotation to %s", path.Join(dir, name))
+				continue
+			}
+		}
+		if _, ok := r.parseName(name); !ok {
+			continue
+		}
+		if err := os.Remove(path.Join(dir, n)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		r.logf("removed temporary file %s left by an interrupted rotation", path.Join(dir, n))
+	}
+	return nil
+}
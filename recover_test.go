@@ -0,0 +1,68 @@
+package rotate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for _, n := range []string{"mt.3.log", "mt.4.log.gz.tmp", "other.tmp"} {
+		if err := ioutil.WriteFile(path.Join(root, n), []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	o := DefaultOptions()
+	o.NamePattern = "{prefix}.{n}.log"
+	x, err := New(root, "mt", WithOptions(o))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := x.GetCounter(); c != 4 {
+		t.Errorf("counter: %d, expected 4", c)
+	}
+	if _, err := os.Stat(path.Join(root, "mt.4.log.gz.tmp")); !os.IsNotExist(err) {
+		t.Errorf("temporary archive not removed: %v", err)
+	}
+	if _, err := os.Stat(path.Join(root, "other.tmp")); err != nil {
+		t.Errorf("unrelated file: %v", err)
+	}
+
+	o.NamePattern = "{bad}"
+	if _, err := New(root, "mt", WithOptions(o)); err == nil {
+		t.Error("New succeeded with a bad name pattern")
+	}
+}
+
+func TestAdoptArchives(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for i := 1; i <= 15; i++ {
+		if err := ioutil.WriteFile(path.Join(root, fmt.Sprintf("mt_%d", i)), []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != keepDefault || archives[0].Name != "mt_6" {
+		t.Errorf("archives: %d starting with %s, expected %d starting with mt_6", len(archives), archives[0].Name, keepDefault)
+	}
+}
@@ -0,0 +1,97 @@
+package rotate
+
+import (
+	"regexp"
+	"strconv"
+)
+
+const redactMaskDefault = "[REDACTED]"
+
+// DefaultRedactPatterns match common credentials: key=value style
+// passwords, secrets, tokens and API keys, and HTTP Authorization
+// header values.
+var DefaultRedactPatterns = []string{
+	`(?i)\b(?:password|passwd|pwd|secret|token|api[_-]?key)\s*[=:]\s*"?([^\s"&,;]+)`,
+	`(?i)\bauthorization:\s*(?:bearer|basic)\s+(\S+)`,
+}
+
+// RedactConfig configures Redact.
+type RedactConfig struct {
+	// Patterns are regular expressions for text to mask.  If a
+	// pattern has a capturing group, only the text matched by the
+	// first group is masked, so a pattern can match "password=x"
+	// and keep "password=".
+	Patterns []string
+
+	// Keys are JSON object keys whose values are masked, at any
+	// depth, in JSON encoded records.  The mask is written as a
+	// JSON string so the record stays valid.
+	Keys []string
+
+	// Mask is written in place of redacted text.  It defaults to
+	// "[REDACTED]".
+	Mask string
+}
+
+type redactRule struct {
+	re   *regexp.Regexp
+	mask []byte
+}
+
+// Redact returns a WriteInterceptor that masks secrets before they
+// are written, configured by c.  Use it with Writer.Use:
+//
+//	redact, err := rotate.Redact(rotate.RedactConfig{
+//		Patterns: rotate.DefaultRedactPatterns,
+//		Keys:     []string{"password", "ssn"},
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	w.Use(redact)
+func Redact(c RedactConfig) (WriteInterceptor, error) {
+	mask := c.Mask
+	if mask == "" {
+		mask = redactMaskDefault
+	}
+	var rules []redactRule
+	for _, p := range c.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, redactRule{re: re, mask: []byte(mask)})
+	}
+	for _, k := range c.Keys {
+		re := regexp.MustCompile(`"` + regexp.QuoteMeta(k) + `"\s*:\s*("(?:[^"\\]|\\.)*"|-?[0-9][0-9.eE+-]*)`)
+		rules = append(rules, redactRule{re: re, mask: []byte(strconv.Quote(mask))})
+	}
+
+	return func(p []byte) []byte {
+		for _, rule := range rules {
+			p = redact(p, rule)
+		}
+		return p
+	}, nil
+}
+
+// redact replaces the matches of rule in p, or their first group,
+// with the mask.  p is only copied if something matched.
+func redact(p []byte, rule redactRule) []byte {
+	locs := rule.re.FindAllSubmatchIndex(p, -1)
+	if locs == nil {
+		return p
+	}
+	out := make([]byte, 0, len(p))
+	last := 0
+	for _, loc := range locs {
+		start, end := loc[0], loc[1]
+		if len(loc) > 2 && loc[2] >= 0 {
+			start, end = loc[2], loc[3]
+		}
+		out = append(out, p[last:start]...)
+		out = append(out, rule.mask...)
+		last = end
+	}
+	return append(out, p[last:]...)
+}
@@ -0,0 +1,31 @@
+package rotate
+
+import "testing"
+
+func TestRedact(t *testing.T) {
+	redact, err := Redact(RedactConfig{
+		Patterns: DefaultRedactPatterns,
+		Keys:     []string{"ssn", "pin"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		in, out string
+	}{
+		{"login user=bob password=hunter2 ok\n", "login user=bob password=[REDACTED] ok\n"},
+		{"Authorization: Bearer abc.def\n", "Authorization: Bearer [REDACTED]\n"},
+		{`{"user":"bob","ssn":"123-45-6789","card":{"pin": 1234}}` + "\n", `{"user":"bob","ssn":"[REDACTED]","card":{"pin": "[REDACTED]"}}` + "\n"},
+		{"nothing to see\n", "nothing to see\n"},
+	}
+	for _, test := range tests {
+		in := []byte(test.in)
+		out := redact(in)
+		if string(out) != test.out {
+			t.Errorf("redact(%q): %q, expected %q", test.in, out, test.out)
+		}
+		if string(in) != test.in {
+			t.Errorf("redact modified its input: %q", in)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package rotate
+
+import (
+	"errors"
+	"sync"
+)
+
+// registry holds the Writers created with WithRegistry, in the order
+// they were created.
+var registry struct {
+	mu      sync.Mutex
+	writers []*Writer
+}
+
+// WithRegistry adds the Writer to a package level registry, so a
+// single signal handler can rotate, flush or close every log of a
+// large program with RotateAll, FlushAll and CloseAll.  A Writer
+// leaves the registry when it is closed.
+func WithRegistry() Option {
+	return func(r *Writer) {
+		r.registered = true
+	}
+}
+
+// register adds r to the registry.
+func register(r *Writer) {
+	registry.mu.Lock()
+	registry.writers = append(registry.writers, r)
+	registry.mu.Unlock()
+}
+
+// unregister removes r from the registry, if it is there.
+func unregister(r *Writer) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	for i, w := range registry.writers {
+		if w == r {
+			registry.writers = append(registry.writers[:i], registry.writers[i+1:]...)
+			return
+		}
+	}
+}
+
+// registered returns the Writers in the registry.
+func registered() []*Writer {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	return append([]*Writer(nil), registry.writers...)
+}
+
+// Rotate rotates the current file now, unless it is empty.
+func (r *Writer) Rotate() error {
+	return r.rotateNow(ReasonManual)
+}
+
+// rotateNow rotates the current file for reason, unless it is empty.
+func (r *Writer) rotateNow(reason RotationReason) error {
+	r.Lock()
+	defer r.Unlock()
+	if r.current == nil && !r.unopened {
+		return ErrClosed
+	}
+	if r.size == 0 {
+		return nil
+	}
+	return r.rotate(reason)
+}
+
+// RotateAll rotates every registered Writer.  It carries on past
+// errors and returns them all joined.
+func RotateAll() error {
+	var errs []error
+	for _, w := range registered() {
+		errs = append(errs, w.rotateNow(ReasonSignal))
+	}
+	return errors.Join(errs...)
+}
+
+// FlushAll flushes the buffer of every registered Writer.  It
+// carries on past errors and returns them all joined.
+func FlushAll() error {
+	var errs []error
+	for _, w := range registered() {
+		errs = append(errs, w.Flush())
+	}
+	return errors.Join(errs...)
+}
+
+// CloseAll closes every registered Writer, which removes it from the
+// registry.  It carries on past errors and returns them all joined.
+func CloseAll() error {
+	var errs []error
+	for _, w := range registered() {
+		errs = append(errs, w.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,51 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	var ws []*Writer
+	for _, prefix := range []string{"a", "b"} {
+		w, err := New(path.Join(root, prefix), prefix, WithRegistry(), WithBuffer(4096))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+		ws = append(ws, w)
+	}
+	if err := FlushAll(); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(path.Join(root, "a", fileDefault)); string(b) != "hello\n" {
+		t.Errorf("a after FlushAll: %q", b)
+	}
+	if err := RotateAll(); err != nil {
+		t.Fatal(err)
+	}
+	for _, prefix := range []string{"a", "b"} {
+		if _, err := os.Stat(path.Join(root, prefix, prefix+"_1")); err != nil {
+			t.Errorf("%s after RotateAll: %v", prefix, err)
+		}
+	}
+	if err := CloseAll(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(registered()); n != 0 {
+		t.Errorf("registered after CloseAll: %d", n)
+	}
+	if err := ws[0].Close(); err != ErrClosed {
+		t.Errorf("close after CloseAll: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package rotate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// partialSuffix is appended to a file's name to name the file that
+// Repair saves its torn final record in.
+const partialSuffix = ".partial"
+
+// RepairOptions controls Repair.
+type RepairOptions struct {
+	// SavePartial makes Repair append the torn record to a file
+	// named after the repaired one with a .partial suffix, rather
+	// than only dropping it.
+	SavePartial bool
+
+	// DryRun makes Repair only report what it would do.
+	DryRun bool
+}
+
+// RepairResult is what Repair did, or would do with DryRun.
+type RepairResult struct {
+	// Size is the size of the file after the repair.
+	Size int64
+
+	// Torn is the number of bytes of the torn final record that
+	// were removed, or zero if the file was intact.
+	Torn int64
+
+	// Partial is the path the torn record was saved to, if any.
+	Partial string
+}
+
+// Repair detects a final record torn by a crash in the log file at
+// p, which is one that does not end with a newline, and truncates the
+// file to the end of the last complete record.  Compressed and
+// encrypted files cannot be repaired this way.  The file must not be
+// in use by a Writer; run Repair before New, or on archives.
+func Repair(p string, o RepairOptions) (RepairResult, error) {
+	var res RepairResult
+	if compressorFor(p) != nil || isEncrypted(p) {
+		return res, fmt.Errorf("rotate: repair %s: compressed or encrypted files cannot be repaired", p)
+	}
+	f, err := os.OpenFile(p, os.O_RDWR, 0)
+	if err != nil {
+		return res, fmt.Errorf("rotate: repair: %w", err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return res, fmt.Errorf("rotate: repair: %w", err)
+	}
+	size := fi.Size()
+	end, err := lastNewline(f, size)
+	if err != nil {
+		return res, fmt.Errorf("rotate: repair %s: %w", p, err)
+	}
+	res.Size, res.Torn = end, size-end
+	if res.Torn == 0 || o.DryRun {
+		return res, nil
+	}
+
+	if o.SavePartial {
+		tail := make([]byte, res.Torn)
+		if _, err := f.ReadAt(tail, end); err != nil && err != io.EOF {
+			return res, fmt.Errorf("rotate: repair %s: %w", p, err)
+		}
+		res.Partial = p + partialSuffix
+		if err := appendSync(res.Partial, append(tail, '\n'), fi.Mode().Perm()); err != nil {
+			res.Partial = ""
+			return res, fmt.Errorf("rotate: save torn record of %s: %w", p, err)
+		}
+	}
+	if err := f.Truncate(end); err != nil {
+		return res, fmt.Errorf("rotate: repair %s: %w", p, err)
+	}
+	if err := f.Sync(); err != nil {
+		return res, fmt.Errorf("rotate: repair %s: %w", p, err)
+	}
+	return res, nil
+}
+
+// lastNewline returns the offset just past the last newline in f,
+// which is size bytes long, or zero if it has none.
+func lastNewline(f io.ReaderAt, size int64) (int64, error) {
+	buf := make([]byte, tailScan)
+	for end := size; end > 0; {
+		off := end - int64(len(buf))
+		if off < 0 {
+			off = 0
+		}
+		b := buf[:end-off]
+		if _, err := f.ReadAt(b, off); err != nil && err != io.EOF {
+			return 0, err
+		}
+		if i := bytes.LastIndexByte(b, '\n'); i >= 0 {
+			return off + int64(i) + 1, nil
+		}
+		end = off
+	}
+	return 0, nil
+}
+
+// appendSync appends b to the file at p, creating it with perm if
+// needed, and syncs it.
+func appendSync(p string, b []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_APPEND, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,50 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestRepair(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	p := path.Join(root, fileDefault)
+	if err := ioutil.WriteFile(p, []byte("one\ntwo\nthr\x00\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	res, err := Repair(p, RepairOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Size != 8 || res.Torn != 5 {
+		t.Errorf("dry run: %+v, expected 5 torn bytes", res)
+	}
+	if b, _ := ioutil.ReadFile(p); len(b) != 13 {
+		t.Errorf("dry run changed the file: %q", b)
+	}
+
+	res, err = Repair(p, RepairOptions{SavePartial: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(p); string(b) != "one\ntwo\n" {
+		t.Errorf("repaired: %q", b)
+	}
+	if b, _ := ioutil.ReadFile(res.Partial); string(b) != "thr\x00\x00\n" {
+		t.Errorf("partial: %q", b)
+	}
+
+	res, err = Repair(p, RepairOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Torn != 0 {
+		t.Errorf("intact file: %+v", res)
+	}
+}
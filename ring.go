@@ -0,0 +1,84 @@
+package rotate
+
+import (
+	"bytes"
+	"time"
+)
+
+// ringRetry is how often a Writer holding data in memory tries to
+// reopen its current file.
+const ringRetry = time.Second
+
+// SetMemoryFallback makes the Writer keep the most recent size bytes
+// in memory when writing to the current file fails, so the lead-up
+// to a disk failure is not lost.  Such writes succeed, and the
+// failure is recorded for Health.  Later writes first try to write
+// what is kept, reopening the current file at most once a second,
+// and it is written as soon as that succeeds.  When more than size
+// bytes are waiting, the oldest whole lines are dropped.  A size of
+// zero turns it off, dropping anything kept.
+func (r *Writer) SetMemoryFallback(size int) {
+	r.Lock()
+	defer r.Unlock()
+	if size <= 0 {
+		r.ring = nil
+		return
+	}
+	if r.ring == nil {
+		r.ring = &ringBuffer{}
+	}
+	r.ring.max = size
+}
+
+// ringBuffer holds the most recent data that could not be written.
+type ringBuffer struct {
+	buf     []byte
+	max     int
+	dropped int64
+	retry   time.Time
+}
+
+// write appends p, dropping the oldest data past the limit, up to a
+// line boundary where there is one.
+func (b *ringBuffer) write(p []byte) {
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - b.max; over > 0 {
+		if i := bytes.IndexByte(b.buf[over:], '\n'); i >= 0 && over+i+1 < len(b.buf) {
+			over += i + 1
+		}
+		b.dropped += int64(over)
+		b.buf = append(b.buf[:0], b.buf[over:]...)
+	}
+}
+
+// flushRing writes the data kept in memory, if any, and reports
+// whether none is left.  The lock must be held.
+func (r *Writer) flushRing() bool {
+	b := r.ring
+	if len(b.buf) == 0 {
+		return true
+	}
+	if time.Now().Before(b.retry) {
+		return false
+	}
+	b.retry = time.Now().Add(ringRetry)
+	// the file may be unusable since the failure, so start afresh
+	r.closeCurrent()
+	if err := r.openCurrent(); err != nil {
+		return false
+	}
+	n, err := r.out.Write(b.buf)
+	r.size += n
+	if err != nil {
+		b.buf = append(b.buf[:0], b.buf[n:]...)
+		return false
+	}
+	if b.dropped > 0 {
+		r.logf("wrote %d bytes kept in memory after %d were dropped", n, b.dropped)
+	} else {
+		r.logf("wrote %d bytes kept in memory", n)
+	}
+	b.buf = b.buf[:0]
+	b.dropped = 0
+	return true
+}
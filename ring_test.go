@@ -0,0 +1,54 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestMemoryFallback(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMemoryFallback(1024)
+	if _, err := x.Write([]byte("x\n")); err != nil {
+		t.Fatal(err)
+	}
+	// make the next write fail
+	x.current.Close()
+	if _, err := x.Write([]byte("one\n")); err != nil {
+		t.Fatalf("write kept in memory: %v", err)
+	}
+	if x.LastError() == nil {
+		t.Error("failure not recorded")
+	}
+	if _, err := x.Write([]byte("two\n")); err != nil {
+		t.Fatal(err)
+	}
+	x.Close()
+	if b, _ := ioutil.ReadFile(path.Join(root, "default.log")); string(b) != "x\none\ntwo\n" {
+		t.Errorf("current: %q", b)
+	}
+}
+
+func TestRingBuffer(t *testing.T) {
+	b := &ringBuffer{max: 8}
+	for _, s := range []string{"one\n", "two\n", "three\n"} {
+		b.write([]byte(s))
+	}
+	if string(b.buf) != "three\n" || b.dropped != 8 {
+		t.Errorf("kept %q, dropped %d", b.buf, b.dropped)
+	}
+	b.write([]byte("a very long line\n"))
+	if string(b.buf) != "ng line\n" {
+		t.Errorf("kept %q, expected the end of the line", b.buf)
+	}
+}
@@ -6,14 +6,18 @@
 package rotate
 
 import (
-	"errors"
+	"bufio"
+	"container/heap"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path"
-	"sort"
-	"strconv"
+	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
@@ -22,98 +26,496 @@ const (
 	fileDefault = "default.log"
 )
 
+// KeepAll, given to SetKeep or as Options.Keep, keeps every archive,
+// for when they are deleted by something else, such as a separate
+// archival job.
+const KeepAll = -1
+
 // RootPerm defines the permissions that Writer will use if it
-// needs to create the root directory.
+// needs to create the root directory.  It is read by New, so
+// changing it does not affect existing Writers.
+//
+// Deprecated: Use WithRootPerm, which does not affect other Writers.
 var RootPerm = os.FileMode(0755)
 
 // FilePerm defines the permissions that Writer will use for all
-// the files it creates.
+// the files it creates.  It is read by New, so changing it does not
+// affect existing Writers.
+//
+// Deprecated: Use WithFilePerm, which does not affect other Writers.
 var FilePerm = os.FileMode(0666)
 
+// ArchiveInfo describes a file that has been rotated out of the
+// current file.
+type ArchiveInfo struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Counter int       `json:"counter"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+
+	// Meta is the archive's recorded metadata, if any.
+	Meta *ArchiveMeta `json:"meta,omitempty"`
+}
+
 // Writer implements the io.Writer interface and writes to the
 // "current" file in the root directory.  When current's size
 // exceeds max, it is renamed and a new file is created.
 type Writer struct {
-	root     string
-	prefix   string
-	fileName string
-	current  *os.File
-	size     int
-	max      int
-	keep     int
-	counter  int
+	root         string
+	archDir      string
+	prefix       string
+	fileName     string
+	current      *os.File
+	opened       time.Time
+	lastRotation time.Time
+	sidecar      bool
+	out          io.Writer
+	enc          io.WriteCloser
+	buf          *bufio.Writer
+	bufSize      int
+	flushNL      bool
+	dirty        bool
+	syncPolicy   SyncPolicy
+	syncStop     chan struct{}
+	live         Compressor
+	liveNext     Compressor
+	tags         map[string]string
+	keys         KeyProvider
+	size         int
+	max          int
+	keep         int
+	maxAge       time.Duration
+	counter      int
+	rootPerm     os.FileMode
+	filePerm     os.FileMode
+	exactPerm    bool
+	openFlags    int
+	registered   bool
+	lazy         bool
+	staged       bool
+	sizeCheck    time.Duration
+	sizeChecked  time.Time
+	passthrough  bool
+	discard      bool
+	inherited    *os.File
+	marker       []byte
+	dueReason    RotationReason
+	windowStart  time.Duration
+	windowEnd    time.Duration
+	compression  CompressionPolicy
+	unopened     bool
+	optErr       error
+	lay          atomic.Value // *layout
+	curPath      atomic.Value // string
+	logger       atomic.Value // loggerHolder
+	delSink      atomic.Value // sinkHolder
+
+	errMu   sync.Mutex
+	lastErr error
+	errTime time.Time
+
+	async             *asyncQueue
+	asyncOpts         *AsyncOptions
+	maxPendingEntries int
+	maxPendingBytes   int
+
+	coord      *Coordinator
+	sink       ArchiveSink
+	sinkEvents SinkEvents
+	onError    func(error)
+	pending    sync.WaitGroup
+	closing    chan struct{}
+
+	manifestMu sync.Mutex
+	statusFile string
+	chain      []ChainLink
+
+	pinMu sync.Mutex
+	pins  map[int]bool
+
+	indexMu     sync.Mutex
+	index       map[string]timeRange
+	timeLayouts []string
+
+	fallback Fallback
+	primary  string
+	nextTry  time.Time
+
+	ring *ringBuffer
+
+	start StartBehavior
+
+	bucket      time.Duration
+	bucketStart time.Time
+
+	multiline     bool
+	recordStart   *regexp.Regexp
+	midLine       bool
+	rotatePending bool
+
+	minFreeBytes  int64
+	minFreeInodes int64
+
+	nfsSafe bool
+	shared  bool
+	flock   *fileLock
+
+	events       events
+	stats        stats
+	onOpen       func(f *os.File) error
+	softLimit    int
+	onSoftLimit  func(size int)
+	softFired    bool
+	interceptors []WriteInterceptor
+	seq          *sequence
+	trigger      Trigger
+	beforeRotate func(path string, size int64) error
+
 	sync.Mutex
 }
 
+// An Option configures a Writer when it is created by New.
+type Option func(*Writer)
+
+// WithRootPerm sets the permissions used to create the root
+// directory and any archive directories.  The default is RootPerm.
+func WithRootPerm(perm os.FileMode) Option {
+	return func(r *Writer) {
+		r.rootPerm = perm
+	}
+}
+
+// WithFilePerm sets the permissions used to create files.  The
+// default is FilePerm.
+func WithFilePerm(perm os.FileMode) Option {
+	return func(r *Writer) {
+		r.filePerm = perm
+	}
+}
+
+// WithOpenFlags adds flags, such as syscall.O_NOATIME on Linux, to
+// those the current file is opened with, os.O_RDWR, os.O_CREATE and
+// os.O_APPEND.  Go already opens files with O_CLOEXEC.
+func WithOpenFlags(extra int) Option {
+	return func(r *Writer) {
+		r.openFlags = extra
+	}
+}
+
 // New creates a new Writer.  The files will be created in the
 // root directory.  root will be created if necessary.  The
-// filenames will start with prefix.
-func New(root, prefix string) (*Writer, error) {
-	l := &Writer{root: root, prefix: prefix, fileName: fileDefault, max: maxDefault, keep: keepDefault, counter: 1}
+// filenames will start with prefix.  Archives already in the
+// directory are subject to retention right away.  If the current
+// file is a character device or a pipe, such as /dev/stdout, it is
+// written to as it is and never rotated.
+func New(root, prefix string, opts ...Option) (w *Writer, err error) {
+	l, err := newWriter(root, prefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.optErr != nil {
+		return nil, l.optErr
+	}
+	if err := l.setupAsync(); err != nil {
+		return nil, err
+	}
+	if l.lazy {
+		l.unopened = true
+		l.curPath.Store(path.Join(l.root, l.currentName()))
+	} else if err := l.open(); err != nil {
+		return nil, err
+	}
+	if l.async != nil {
+		goLabeled("async", l.prefix, l.drain)
+	}
+	if l.registered {
+		register(l)
+	}
+	return l, nil
+}
+
+// open creates the root if necessary, opens the current file and
+// recovers the state left by earlier runs.  The lock must be held,
+// unless the Writer is still being created.
+func (l *Writer) open() (err error) {
+	if l.discard {
+		return l.openDiscard()
+	}
+	if ok, err := l.openPassthrough(); ok || err != nil {
+		return err
+	}
+	if l.shared {
+		if err := l.setupShared(); err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil {
+				l.flock.close()
+				l.flock = nil
+			} else {
+				l.unlockShared()
+			}
+		}()
+	}
 	if err := l.setup(); err != nil {
+		return err
+	}
+	if err := l.recoverState(); err != nil {
+		l.closeCurrent()
+		return err
+	}
+	if err := l.recoverSequence(); err != nil {
+		l.closeCurrent()
+		return err
+	}
+	if err := l.startCurrent(); err != nil {
+		l.closeCurrent()
+		return err
+	}
+	// apply retention to archives left by earlier runs
+	if err := l.clean(); err != nil {
+		l.closeCurrent()
+		return err
+	}
+	if err := l.writeManifest(); err != nil {
+		l.closeCurrent()
+		return err
+	}
+	return nil
+}
+
+// newWriter returns a Writer with the default settings and no
+// current file.
+func newWriter(root, prefix string) (*Writer, error) {
+	if err := checkName("prefix", prefix); err != nil {
+		return nil, err
+	}
+	l := &Writer{root: root, prefix: prefix, fileName: fileDefault, max: maxDefault, keep: keepDefault, counter: 1, rootPerm: RootPerm, filePerm: FilePerm, sinkEvents: SinkAfterRotation, closing: make(chan struct{})}
+	nameRE, err := l.compileNamePattern(namePatternDefault)
+	if err != nil {
 		return nil, err
 	}
+	l.lay.Store(&layout{root: root, namePattern: namePatternDefault, nameRE: nameRE})
 	return l, nil
 }
 
-// SetMax sets the maximum size for a file in bytes.
+// SetMax sets the maximum size for a file in bytes.  It has no
+// effect while a Trigger is set.
 func (r *Writer) SetMax(size int) {
+	r.Lock()
 	r.max = size
+	r.Unlock()
 }
 
 // SetMaxMiB sets the maximum size for a file in Mebibyte.
 func (r *Writer) SetMaxMiB(size int) {
-	r.max = size * 1024 * 1024
+	r.SetMax(size * 1024 * 1024)
 }
 
-// SetFileName sets the file name.
+// SetFileName sets the file name.  An invalid name, such as one
+// with a path separator, is ignored and the error is recorded for
+// Health; use WithFileName to have New return it.
 func (r *Writer) SetFileName(name string) {
+	if err := checkFileName(name); err != nil {
+		r.recordError(err)
+		return
+	}
+	r.Lock()
 	r.fileName = name
+	r.Unlock()
+}
+
+// WithFileName makes New use name for the current file.
+func WithFileName(name string) Option {
+	return func(r *Writer) {
+		if err := checkFileName(name); err != nil {
+			r.optErr = err
+			return
+		}
+		r.fileName = name
+	}
+}
+
+// WithStagedCurrent makes the current file a hidden one, the file
+// name with a dot in front, so log shippers that glob the root never
+// pick up a file still being written; the data appears under its
+// archive name at rotation.  It has no effect with time buckets,
+// which are never renamed.
+func WithStagedCurrent() Option {
+	return func(r *Writer) {
+		r.staged = true
+	}
+}
+
+// checkName returns an error if name, from a configuration file
+// perhaps, would place a file outside the root.
+func checkName(what, name string) error {
+	if name == "." || name == ".." || strings.ContainsAny(name, "/\\\x00") {
+		return fmt.Errorf("%w: %s %q", ErrInvalidName, what, name)
+	}
+	return nil
 }
 
-// SetKeep sets the number of archived files to keep.
+func checkFileName(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: empty file name", ErrInvalidName)
+	}
+	return checkName("file name", name)
+}
+
+// SetMaxAge sets how long archives are kept after they were last
+// written; older ones are deleted at the next rotation, whatever
+// SetKeep allows.  Zero, the default, keeps archives regardless of
+// age.  See ParseAge for reading ages such as "7d" from
+// configuration files.
+func (r *Writer) SetMaxAge(d time.Duration) {
+	r.Lock()
+	r.maxAge = d
+	r.Unlock()
+}
+
+// SetKeep sets the number of archived files to keep, or KeepAll to
+// never delete any.
 func (r *Writer) SetKeep(n int) {
+	r.Lock()
 	r.keep = n
+	r.Unlock()
 }
 
 // SetCounter sets the starting writer counter.
 func (r *Writer) SetCounter(c int) {
+	r.Lock()
 	r.counter = c
+	r.Unlock()
 }
 
 // GetCounter return current counter.
 func (r *Writer) GetCounter() int {
+	r.Lock()
+	defer r.Unlock()
 	return r.counter
 }
 
 // Write writes p to the current file, then checks to see if
 // rotation is necessary.
 func (r *Writer) Write(p []byte) (n int, err error) {
+	if r.async != nil {
+		return r.async.enqueue(p, false)
+	}
 	r.Lock()
 	defer r.Unlock()
-	n, err = r.current.Write(p)
+	if err := r.ready(); err != nil {
+		return 0, err
+	}
+	return r.writeData(p)
+}
+
+// writeData writes p through the interceptors, if any.  The lock
+// must be held.
+func (r *Writer) writeData(p []byte) (int, error) {
+	if r.intercepting() {
+		return r.writeIntercepted(p)
+	}
+	return r.write(p)
+}
+
+// write writes p to the current file and rotates if needed.  The
+// lock must be held.
+func (r *Writer) write(p []byte) (n int, err error) {
+	if err := r.lockShared(); err != nil {
+		return 0, err
+	}
+	defer r.unlockShared()
+	r.switchBack()
+	if err := r.rollBucket(); err != nil {
+		return 0, err
+	}
+	if err := r.rotateBefore(p); err != nil {
+		return 0, err
+	}
+	r.checkSize()
+	if r.ring != nil && !r.flushRing() {
+		r.ring.write(p)
+		return len(p), nil
+	}
+	n, err = r.out.Write(p)
+	if err != nil && r.failover(err) {
+		var m int
+		m, err = r.out.Write(p[n:])
+		n += m
+	}
+	if err != nil && r.ring != nil {
+		r.recordError(fmt.Errorf("rotate: write failed, keeping data in memory: %w", err))
+		r.ring.write(p[n:])
+		r.size += n
+		return len(p), nil
+	}
 	if err != nil {
-		return n, err
+		return n, fmt.Errorf("rotate: write: %w", err)
 	}
 	r.size += n
-	if r.size >= r.max {
-		if err := r.rotate(); err != nil {
-			return n, err
-		}
+	r.dirty = true
+	r.countWrite(n)
+	r.checkSoftLimit()
+	if err := r.flushLine(p[:n]); err != nil {
+		return n, err
+	}
+	if err := r.rotateAfter(p[:n], r.written(p[:n])); err != nil {
+		return n, err
 	}
 	return n, nil
 }
 
-// Close closes the current file.  Writer is unusable after this
-// is called.
+// Close closes the current file and waits for any archives still
+// being sent to the sink.  Writer is unusable after this is called.
 func (r *Writer) Close() error {
+	if r.async != nil {
+		if err := r.async.close(); err != nil {
+			return err
+		}
+	}
 	r.Lock()
 	defer r.Unlock()
-	if err := r.current.Close(); err != nil {
-		return err
+	if r.unopened {
+		r.unopened = false
+		r.finishClose()
+		return nil
+	}
+	if r.current == nil {
+		return ErrClosed
 	}
+	if r.ring != nil {
+		r.ring.retry = time.Time{}
+		if !r.flushRing() {
+			r.logf("dropped %d bytes kept in memory", len(r.ring.buf))
+		}
+	}
+	serr := r.syncDirty()
+	err := r.closeCurrent()
 	r.current = nil
-	return nil
+	r.finishClose()
+	if err != nil {
+		return fmt.Errorf("rotate: close current file: %w", err)
+	}
+	return serr
+}
+
+// finishClose stops the background work of a Writer whose current
+// file is closed.  The lock must be held.
+func (r *Writer) finishClose() {
+	close(r.closing)
+	if r.flock != nil {
+		r.flock.close()
+	}
+	r.pending.Wait()
+	r.closeEvents()
+	if r.registered {
+		unregister(r)
+	}
 }
 
 // setup creates the root directory if necessary, then opens the
@@ -121,14 +523,14 @@ func (r *Writer) Close() error {
 func (r *Writer) setup() error {
 	fi, err := os.Stat(r.root)
 	if err != nil && os.IsNotExist(err) {
-		err := os.MkdirAll(r.root, RootPerm)
+		err := r.mkdirAll(r.root)
 		if err != nil {
-			return err
+			return fmt.Errorf("rotate: create root: %w", err)
 		}
 	} else if err != nil {
-		return err
+		return fmt.Errorf("rotate: stat root: %w", err)
 	} else if !fi.IsDir() {
-		return errors.New("root must be a directory")
+		return fmt.Errorf("%w: %s", ErrRootNotDir, r.root)
 	}
 
 	// root exists, and it is a directory
@@ -136,65 +538,460 @@ func (r *Writer) setup() error {
 	return r.openCurrent()
 }
 
+// currentName returns the name of the current file, which carries
+// the compressor's extension when live compression is on, and is
+// hidden with WithStagedCurrent.  The lock must be held.
+func (r *Writer) currentName() string {
+	name := r.fileName
+	if r.live != nil {
+		name += r.live.Ext()
+	}
+	if r.staged && r.bucket == 0 {
+		name = "." + name
+	}
+	return name
+}
+
+// currentPath returns the path of the current file.  It does not
+// need the lock.
+func (r *Writer) currentPath() string {
+	return r.curPath.Load().(string)
+}
+
 func (r *Writer) openCurrent() error {
-	cp := path.Join(r.root, r.fileName)
-	var err error
-	r.current, err = os.OpenFile(cp, os.O_RDWR|os.O_CREATE|os.O_APPEND, FilePerm)
-	if err != nil {
+	if r.bucket > 0 {
+		r.nameBucket(time.Now())
+	}
+	cp := path.Join(r.root, r.currentName())
+	if r.inherited != nil {
+		return r.openInherited(cp)
+	}
+	var f *os.File
+	err := retryStale(func() (err error) {
+		f, err = os.OpenFile(cp, os.O_RDWR|os.O_CREATE|os.O_APPEND|r.openFlags, r.filePerm)
 		return err
+	})
+	if err != nil {
+		// r.current is left as it was, so the Writer is not
+		// mistaken for closed
+		return fmt.Errorf("rotate: open current file: %w", err)
+	}
+	if err := r.fixFilePerm(f); err != nil {
+		f.Close()
+		return fmt.Errorf("rotate: set permissions of %s: %w", cp, err)
+	}
+	return r.useCurrent(f, cp)
+}
+
+// useCurrent makes f, just opened at cp, the current file.  The lock
+// must be held.
+func (r *Writer) useCurrent(f *os.File, cp string) (err error) {
+	r.current = f
+	r.out = r.current
+	r.enc = nil
+	if r.live != nil {
+		r.enc, err = r.live.NewWriter(r.current)
+		if err != nil {
+			r.current.Close()
+			return fmt.Errorf("rotate: start compressing %s: %w", cp, err)
+		}
+		r.out = r.enc
+	}
+	r.dirty = false
+	r.softFired = false
+	r.buf = nil
+	if r.bufSize > 0 {
+		r.buf = bufio.NewWriterSize(r.out, r.bufSize)
+		r.out = r.buf
 	}
+	r.rotatePending = false
+	// an existing file counts toward the maximum size, except
+	// when compressed, as its uncompressed size is not known
 	r.size = 0
+	if r.enc == nil {
+		fi, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("rotate: stat current file: %w", err)
+		}
+		r.size = int(fi.Size())
+	}
+	r.opened = time.Now()
+	if r.trigger != nil {
+		r.resetTrigger()
+	}
+	r.curPath.Store(cp)
+	r.logf("opened %s", cp)
+	if r.onOpen != nil {
+		if err := r.onOpen(f); err != nil {
+			r.recordError(fmt.Errorf("rotate: set up %s: %w", cp, err))
+		}
+	}
 	return nil
 }
 
-func (r *Writer) rotate() error {
-	if err := r.current.Close(); err != nil {
+// closeCurrent flushes the buffer and the live compressor, if any,
+// and closes the current file.
+func (r *Writer) closeCurrent() error {
+	if r.buf != nil {
+		if err := r.buf.Flush(); err != nil {
+			if r.enc != nil {
+				r.enc.Close()
+			}
+			r.current.Close()
+			return err
+		}
+	}
+	if r.enc != nil {
+		if err := r.enc.Close(); err != nil {
+			r.current.Close()
+			return err
+		}
+	}
+	return r.current.Close()
+}
+
+// rotate archives the current file and opens a new one.  Errors are
+// recorded for Health as well as returned.  The lock must be held.
+func (r *Writer) rotate(reason RotationReason) error {
+	if r.unopened || r.passthrough {
+		return nil
+	}
+	if r.discard {
+		r.rotateDiscard()
+		return nil
+	}
+	if r.current == nil {
+		return ErrClosed
+	}
+	if r.bucket > 0 {
+		// time buckets are never renamed
+		return nil
+	}
+	if r.beforeRotate != nil {
+		if err := r.beforeRotate(r.currentPath(), int64(r.size)); err != nil {
+			r.logf("rotation of %s postponed: %v", r.currentName(), err)
+			return nil
+		}
+	}
+	if r.coord != nil {
+		defer r.coord.acquire()()
+	}
+	if err := r.lockShared(); err != nil {
+		r.recordError(err)
 		return err
 	}
-	filename := fmt.Sprintf("%s_%d", r.prefix, r.counter)
-	if err := os.Rename(path.Join(r.root, r.fileName), path.Join(r.root, filename)); err != nil {
+	defer r.unlockShared()
+	var err error
+	region("rotate", func() { err = r.rotateCurrent(reason) })
+	if err != nil {
+		r.recordError(err)
 		return err
 	}
-	if err := r.clean(); err != nil {
+	return nil
+}
+
+func (r *Writer) rotateCurrent(reason RotationReason) error {
+	if r.flock != nil {
+		// another process may have rotated since
+		if err := r.advanceCounter(); err != nil {
+			return fmt.Errorf("rotate: list archives: %w", err)
+		}
+	}
+	if err := r.ensureFree(); err != nil {
 		return err
 	}
+	now := time.Now()
+	filename := r.archiveName(r.counter, now)
+	if lay := r.layout(); lay.dated {
+		dir, err := r.makeDatedDir(now)
+		if err != nil {
+			return fmt.Errorf("rotate: create archive directory: %w", err)
+		}
+		filename = path.Join(dir, filename)
+	} else if lay.archDir != "" {
+		if err := r.mkdirAll(lay.archDir); err != nil {
+			return fmt.Errorf("rotate: create archive directory: %w", err)
+		}
+	}
+	if _, err := os.Stat(path.Join(r.archiveRoot(), filename)); err == nil {
+		r.logf("not rotating: archive %s already exists", filename)
+		return fmt.Errorf("%w: %s", ErrNameCollision, filename)
+	}
+	if err := r.syncDirty(); err != nil {
+		return err
+	}
+	var size int64
+	if r.nfsSafe {
+		if err := r.flush(); err != nil {
+			return err
+		}
+		fi, err := r.current.Stat()
+		if err != nil {
+			return fmt.Errorf("rotate: stat current file: %w", err)
+		}
+		size = fi.Size()
+	}
+	if err := r.closeCurrent(); err != nil {
+		return fmt.Errorf("rotate: close current file: %w", err)
+	}
+	src, dst := path.Join(r.root, r.currentName()), path.Join(r.archiveRoot(), filename)
+	if r.nfsSafe {
+		if err := moveFileNFS(src, dst); err != nil {
+			return fmt.Errorf("rotate: move current file to archive: %w", err)
+		}
+		if err := verifySize(dst, size); err != nil {
+			// the data is where it is; keep writing
+			r.recordError(fmt.Errorf("rotate: verify archive: %w", err))
+		}
+	} else if err := moveFile(src, dst); err != nil {
+		return fmt.Errorf("rotate: move current file to archive: %w", err)
+	}
+	// a copy to another filesystem was made under the umask
+	if err := r.fixPerm(dst); err != nil {
+		r.recordError(fmt.Errorf("rotate: set permissions of %s: %w", dst, err))
+	}
+	r.logf("rotated %s to %s (%d bytes)", r.currentName(), dst, r.size)
+
+	counter, start, live, tags := r.counter, r.opened, r.live, r.tags
 	r.counter = r.counter + 1
-	return r.openCurrent()
+	r.live = r.liveNext
+	r.tags = nil
+	if err := r.openCurrent(); err != nil {
+		return err
+	}
+	r.lastRotation = now
+	atomic.AddUint64(&r.stats.rotations, 1)
+	return r.finishRotation(filename, counter, start, now, live, reason, tags)
 }
 
-func (r *Writer) clean() error {
-	d, err := os.Open(r.root)
+// finishRotation does the work that follows the rotation of an
+// archive, once the new current file is open so that its errors do
+// not stop writes.  The lock must be held.
+func (r *Writer) finishRotation(filename string, counter int, start, end time.Time, live Compressor, reason RotationReason, tags map[string]string) error {
+	if r.layout().syncDirs {
+		if err := syncDirs(r.root, path.Dir(path.Join(r.archiveRoot(), filename))); err != nil {
+			return fmt.Errorf("rotate: sync directories: %w", err)
+		}
+	}
+	info, err := r.archiveInfo(filename, counter)
 	if err != nil {
+		return fmt.Errorf("rotate: stat archive: %w", err)
+	}
+	if r.sidecar {
+		if info.Meta, err = writeMeta(info, start, end, live, reason, tags, r.filePerm); err != nil {
+			return fmt.Errorf("rotate: write metadata for %s: %w", filename, err)
+		}
+		if err := r.fixPerm(info.Path + metaSuffix); err != nil {
+			return fmt.Errorf("rotate: set permissions of metadata for %s: %w", filename, err)
+		}
+	}
+	if err := r.protectArchive(info.Path); err != nil {
 		return err
 	}
-	defer d.Close()
-	names, err := d.Readdirnames(1024)
+	if err := r.chainAdded(info); err != nil {
+		return err
+	}
+	r.emit(Event{Kind: EventRotated, Time: end, Archive: info, Reason: reason})
+	// the sink gets the archive once the compression policy has run,
+	// which may have replaced it
+	defer func() { r.store(info) }()
+	if err := r.writeStatus(end); err != nil {
+		return fmt.Errorf("rotate: write status file: %w", err)
+	}
+	region("clean", func() {
+		if err = r.clean(); err == nil && r.coord != nil {
+			err = r.coord.Clean()
+		}
+	})
+	if err != nil {
+		return err
+	}
+	compressed, err := r.compressOld()
+	if c, ok := compressed[info.Path]; ok {
+		info = c
+	}
 	if err != nil {
 		return err
 	}
-	var archNames []string
-	for _, n := range names {
-		if strings.HasPrefix(n, r.prefix+"_") {
-			archNames = append(archNames, n)
+	return r.writeManifest()
+}
+
+func (r *Writer) clean() error {
+	_, err := r.cleanArchives(context.Background(), r.keep, r.maxAge, false)
+	return err
+}
+
+// cleanArchives deletes the oldest archives beyond keep and those
+// last written more than maxAge ago, if it is set, and returns them,
+// or only returns them if dryRun is set.  The lock must be held.
+func (r *Writer) cleanArchives(ctx context.Context, keep int, maxAge time.Duration, dryRun bool) ([]ArchiveInfo, error) {
+	if (keep < 0 && maxAge <= 0) || r.layout().hold {
+		return nil, nil
+	}
+	cutoff := time.Now().Add(-maxAge)
+	// only the newest keep archives and those to delete are held,
+	// however many files the directory has
+	var newest archiveHeap
+	var toDel []ArchiveInfo
+	err := r.walkArchives(func(a ArchiveInfo) {
+		if r.isExempt(a) {
+			return
+		}
+		if maxAge > 0 && a.ModTime.Before(cutoff) {
+			toDel = append(toDel, a)
+			return
+		}
+		if keep < 0 {
+			return
+		}
+		heap.Push(&newest, a)
+		if newest.Len() > keep {
+			toDel = append(toDel, heap.Pop(&newest).(ArchiveInfo))
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("rotate: list archives: %w", err)
+	}
+	if len(toDel) == 0 {
+		return nil, nil
+	}
+	sortArchives(toDel)
+	if dryRun {
+		return toDel, nil
+	}
+	var dirs []string
+	for i, a := range toDel {
+		if err := ctx.Err(); err != nil {
+			return toDel[:i], err
 		}
+		if err := r.removeArchive(a); err != nil {
+			return toDel[:i], fmt.Errorf("rotate: delete archive: %w", err)
+		}
+		dirs = append(dirs, path.Dir(a.Path))
 	}
-	if len(archNames) <= r.keep {
-		return nil
+	if r.layout().syncDirs {
+		if err := syncDirs(dirs...); err != nil {
+			return toDel, fmt.Errorf("rotate: sync directories: %w", err)
+		}
+	}
+	return toDel, nil
+}
+
+// Archives returns the archived files in the root directory,
+// oldest first, with their metadata if it was recorded.
+func (r *Writer) Archives() ([]ArchiveInfo, error) {
+	archives, err := r.archives()
+	if err != nil {
+		return nil, fmt.Errorf("rotate: list archives: %w", err)
+	}
+	for i := range archives {
+		archives[i].Meta = readMeta(archives[i].Path)
+	}
+	return archives, nil
+}
+
+// removeArchive deletes an archive and its metadata, after storing
+// it in the sink if SinkBeforeDeletion is set.
+func (r *Writer) removeArchive(a ArchiveInfo) error {
+	if err := r.storeBeforeDelete(a); err != nil {
+		return err
 	}
+	r.unprotectArchive(a.Path)
+	if err := os.Remove(a.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	r.logf("deleted %s", a.Path)
+	r.chainDeleted(a)
+	atomic.AddUint64(&r.stats.deleted, 1)
+	r.emit(Event{Kind: EventDeleted, Archive: a})
+	if err := os.Remove(a.Path + metaSuffix); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if r.layout().dated {
+		r.removeEmptyDirs(path.Dir(a.Name))
+	}
+	return nil
+}
 
-	sort.Slice(archNames, func(i, j int) bool {
-		si := strings.Split(archNames[i], "_")
-		sj := strings.Split(archNames[j], "_")
-		ii, _ := strconv.Atoi(si[1])
-		jj, _ := strconv.Atoi(sj[1])
-		return ii < jj
+// archives lists the files in root that were rotated by this
+// Writer, sorted by counter, then by modification time.
+func (r *Writer) archives() ([]ArchiveInfo, error) {
+	var archives []ArchiveInfo
+	err := r.walkArchives(func(a ArchiveInfo) {
+		archives = append(archives, a)
 	})
+	if err != nil {
+		return nil, err
+	}
+	sortArchives(archives)
+	return archives, nil
+}
 
-	toDel := archNames[0 : len(archNames)-r.keep]
-	for _, n := range toDel {
-		if err := os.Remove(path.Join(r.root, n)); err != nil {
+// walkArchives calls fn with each archive, in no particular order.
+func (r *Writer) walkArchives(fn func(ArchiveInfo)) error {
+	if err := r.scanArchives("", fn); err != nil {
+		return err
+	}
+	if r.layout().dated {
+		dirs, err := r.datedDirs()
+		if err != nil {
 			return err
 		}
+		for _, dir := range dirs {
+			if err := r.scanArchives(dir, fn); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
+
+// scanBatch is the number of names read from a directory at a time.
+const scanBatch = 1024
+
+// scanArchives calls fn with each archive in dir, relative to root.
+// The names are read a batch at a time, so a directory with a great
+// many files does not have to fit in memory.
+func (r *Writer) scanArchives(dir string, fn func(ArchiveInfo)) error {
+	d, err := os.Open(path.Join(r.archiveRoot(), dir))
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	cp, _ := r.curPath.Load().(string)
+	for {
+		names, err := d.Readdirnames(scanBatch)
+		for _, n := range names {
+			counter, ok := r.parseName(n)
+			if !ok {
+				continue
+			}
+			if path.Join(r.archiveRoot(), dir, n) == cp {
+				// the current time bucket
+				continue
+			}
+			a, err := r.archiveInfo(path.Join(dir, n), counter)
+			if err != nil {
+				// removed since Readdirnames
+				continue
+			}
+			fn(a)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (r *Writer) archiveInfo(name string, counter int) (ArchiveInfo, error) {
+	p := path.Join(r.archiveRoot(), name)
+	fi, err := os.Stat(p)
+	if err != nil {
+		return ArchiveInfo{}, err
+	}
+	return ArchiveInfo{Name: name, Path: p, Counter: counter, Size: fi.Size(), ModTime: fi.ModTime()}, nil
+}
@@ -1,8 +1,10 @@
 package rotate
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
+	"path"
 	"testing"
 )
 
@@ -68,3 +70,110 @@ func TestRotate(t *testing.T) {
 		}
 	}
 }
+
+func TestConcurrentConfig(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(64)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			x.SetMax(32 + i)
+			x.SetKeep(i % 5)
+			x.SetDatedDirs(i%2 == 0)
+			x.GetCounter()
+			if _, err := x.Archives(); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+}
+
+func TestPerm(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(path.Join(root, "logs"), "mt", WithRootPerm(0700), WithFilePerm(0600))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	fi, err := os.Stat(path.Join(root, "logs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0700 {
+		t.Errorf("root mode: %v, expected %v", fi.Mode().Perm(), os.FileMode(0700))
+	}
+	fi, err = os.Stat(path.Join(root, "logs", fileDefault))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("file mode: %v, expected %v", fi.Mode().Perm(), os.FileMode(0600))
+	}
+}
+
+func TestSyncDirs(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetSyncDirs(true)
+	x.SetMax(5)
+	x.SetKeep(1)
+	for i := 0; i < 3; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 1 {
+		t.Errorf("archives: %d, expected 1", len(archives))
+	}
+}
+
+func TestOpenFlags(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt", WithOpenFlags(os.O_EXCL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.Close()
+	// with O_EXCL, the file left behind cannot be opened again
+	if _, err := New(root, "mt", WithOpenFlags(os.O_EXCL)); !errors.Is(err, os.ErrExist) {
+		t.Errorf("New with O_EXCL on an existing file: %v", err)
+	}
+}
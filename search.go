@@ -0,0 +1,170 @@
+package rotate
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path"
+	"regexp"
+)
+
+// SearchOptions controls Search.
+type SearchOptions struct {
+	// MaxResults stops the search once this many lines have
+	// matched.  Zero means no limit.
+	MaxResults int
+
+	// SkipCurrent excludes the current file from the search.
+	SkipCurrent bool
+}
+
+// Match is a line found by Search.
+type Match struct {
+	// File is the name of the archive or current file.
+	File string `json:"file"`
+
+	// Offset is the position of the line in the file.  For
+	// compressed archives it is the offset in the decompressed
+	// data.
+	Offset int64 `json:"offset"`
+
+	// Line is the matching line without its newline.
+	Line string `json:"line"`
+}
+
+// Search returns the lines matching the regular expression pattern
+// in the archives, oldest first, followed by the current file.
+// Compressed archives are decompressed as they are read.
+func (r *Writer) Search(ctx context.Context, pattern string, opts SearchOptions) ([]Match, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	archives, err := r.archives()
+	if err != nil {
+		return nil, err
+	}
+	files := make([]ArchiveInfo, 0, len(archives)+1)
+	files = append(files, archives...)
+	if !opts.SkipCurrent {
+		cp := r.currentPath()
+		files = append(files, ArchiveInfo{Name: path.Base(cp), Path: cp})
+	}
+
+	var matches []Match
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return matches, err
+		}
+		matches, err = searchFile(ctx, f, re, opts.MaxResults, matches, r.keyProvider())
+		if err != nil {
+			if os.IsNotExist(err) {
+				// deleted by clean while searching
+				continue
+			}
+			return matches, err
+		}
+		if opts.MaxResults > 0 && len(matches) >= opts.MaxResults {
+			break
+		}
+	}
+	return matches, nil
+}
+
+func searchFile(ctx context.Context, a ArchiveInfo, re *regexp.Regexp, max int, matches []Match, kp KeyProvider) ([]Match, error) {
+	rc, err := openDecompressed(a.Path, kp)
+	if err != nil {
+		return matches, err
+	}
+	defer rc.Close()
+
+	br := bufio.NewReader(rc)
+	var off int64
+	for n := 0; ; n++ {
+		if n%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return matches, err
+			}
+		}
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			if re.Match(line) {
+				matches = append(matches, Match{File: a.Name, Offset: off, Line: string(bytes.TrimSuffix(line, []byte("\n")))})
+				if max > 0 && len(matches) >= max {
+					return matches, nil
+				}
+			}
+			off += int64(len(line))
+		}
+		// a live compressed current file ends mid-stream
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return matches, nil
+		}
+		if err != nil {
+			return matches, err
+		}
+	}
+}
+
+// openDecompressed opens the file at p, transparently decompressing
+// it if its extension is that of a known Compressor or if it starts
+// with a gzip header, and decrypting it with keys from kp if it is
+// encrypted.
+func openDecompressed(p string, kp KeyProvider) (io.ReadCloser, error) {
+	c := compressorFor(p)
+	if isEncrypted(p) {
+		var err error
+		if c, err = decrypterFor(p, kp); err != nil {
+			return nil, err
+		}
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	if c != nil {
+		// a live compressed current file is empty until the
+		// first write, without even a header
+		if fi, err := f.Stat(); err == nil && fi.Size() == 0 {
+			return f, nil
+		}
+		rc, err := c.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &readCloser{Reader: rc, Closer: multiCloser{rc, f}}, nil
+	}
+	br := bufio.NewReader(f)
+	magic, _ := br.Peek(2)
+	if len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+		return &readCloser{Reader: br, Closer: f}, nil
+	}
+	gz, err := gzip.NewReader(br)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &readCloser{Reader: gz, Closer: f}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// multiCloser closes all its members, returning the first error.
+type multiCloser []io.Closer
+
+func (m multiCloser) Close() error {
+	var first error
+	for _, c := range m {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
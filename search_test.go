@@ -0,0 +1,89 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(10)
+	for _, s := range []string{"one\nerror a\n", "two\n", "error b\n"} {
+		if _, err := x.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// compress the first archive in place
+	p := path.Join(root, "mt_1")
+	data, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write(data)
+	gz.Close()
+	f.Close()
+
+	matches, err := x.Search(context.Background(), "^error", SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Match{
+		{File: "mt_1", Offset: 4, Line: "error a"},
+		{File: "mt_2", Offset: 4, Line: "error b"},
+	}
+	if len(matches) != len(expected) {
+		t.Fatalf("matches: %+v, expected %+v", matches, expected)
+	}
+	for i, m := range matches {
+		if m != expected[i] {
+			t.Errorf("match %d: %+v, expected %+v", i, m, expected[i])
+		}
+	}
+}
+
+func TestSearchEmptyCompressed(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	// the current file has no gzip header until the first write
+	x, err := New(root, "mt", WithLiveCompression(Gzip))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	matches, err := x.Search(context.Background(), "foo", SearchOptions{})
+	if err != nil || len(matches) != 0 {
+		t.Errorf("search: %+v %v", matches, err)
+	}
+	rc, err := x.OpenArchive(ArchiveInfo{Path: x.currentPath()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(rc)
+	rc.Close()
+	if err != nil || len(data) != 0 {
+		t.Errorf("read %q: %v", data, err)
+	}
+}
@@ -0,0 +1,84 @@
+package rotate
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// seqScan is the number of lines searched for the last sequence
+// number when a Writer starts.
+const seqScan = 16
+
+// WithSequence makes the Writer start every line with a sequence
+// number and a space, counting up by one per line across rotations
+// and restarts, so consumers can tell when lines were lost or
+// duplicated.  New carries on from the last number in the current
+// file or the newest archive.  The number is added after the
+// interceptors run, so lines they drop do not leave a gap.
+func WithSequence() Option {
+	return func(r *Writer) {
+		r.seq = &sequence{next: 1, lineStart: true}
+	}
+}
+
+// sequence numbers lines.
+type sequence struct {
+	next      uint64
+	lineStart bool
+}
+
+// prefix returns p with the next sequence numbers at the start of
+// its lines.
+func (s *sequence) prefix(p []byte) []byte {
+	out := make([]byte, 0, len(p)+16)
+	for len(p) > 0 {
+		if s.lineStart {
+			out = strconv.AppendUint(out, s.next, 10)
+			out = append(out, ' ')
+			s.next++
+			s.lineStart = false
+		}
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			out = append(out, p...)
+			break
+		}
+		out = append(out, p[:i+1]...)
+		p = p[i+1:]
+		s.lineStart = true
+	}
+	return out
+}
+
+// recoverSequence continues the sequence from the last numbered line
+// written by an earlier run.  It is called by New.
+func (r *Writer) recoverSequence() error {
+	if r.seq == nil {
+		return nil
+	}
+	// a compressed current file cannot be read from the end
+	f := r.current
+	if r.enc != nil {
+		f = nil
+	}
+	b, _, err := r.lastLines(f, seqScan)
+	if err != nil {
+		return fmt.Errorf("rotate: read last sequence number: %w", err)
+	}
+	lines := bytes.Split(bytes.TrimSuffix(b, newline), newline)
+	for i := len(lines) - 1; i >= 0; i-- {
+		sp := bytes.IndexByte(lines[i], ' ')
+		if sp < 0 {
+			continue
+		}
+		n, err := strconv.ParseUint(string(lines[i][:sp]), 10, 64)
+		if err != nil {
+			continue
+		}
+		r.seq.next = n + 1
+		r.logf("continuing sequence from %d", r.seq.next)
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestSequence(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt", WithSequence())
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(8)
+	for _, s := range []string{"a\nb\n", "c", "\n"} {
+		if _, err := x.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	x.Close()
+	if b, _ := ioutil.ReadFile(path.Join(root, "mt_1")); string(b) != "1 a\n2 b\n" {
+		t.Errorf("archive: %q", b)
+	}
+
+	x, err = New(root, "mt", WithSequence())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.Write([]byte("d\n")); err != nil {
+		t.Fatal(err)
+	}
+	x.Close()
+	if b, _ := ioutil.ReadFile(path.Join(root, fileDefault)); string(b) != "3 c\n4 d\n" {
+		t.Errorf("current after restart: %q", b)
+	}
+}
@@ -0,0 +1,129 @@
+package rotate
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// maxLine is the longest line Serve writes as one record.  Longer
+// lines are written in pieces.
+const maxLine = 64 * 1024
+
+// Serve accepts connections on l and writes the lines received on
+// each of them to w, one Write per line, so lines sent by different
+// clients at once are not mixed up.  It returns when ctx is done,
+// after closing l and the connections, or when Accept fails.
+func Serve(ctx context.Context, l net.Listener, w *Writer) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	conns := make(map[net.Conn]bool)
+	stop := make(chan struct{})
+	defer func() {
+		close(stop)
+		mu.Lock()
+		for c := range conns {
+			c.Close()
+		}
+		mu.Unlock()
+		wg.Wait()
+	}()
+	go func() {
+		select {
+		case <-ctx.Done():
+			l.Close()
+		case <-stop:
+		}
+	}()
+
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("rotate: accept: %w", err)
+		}
+		mu.Lock()
+		conns[c] = true
+		mu.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := serveConn(c, w); err != nil {
+				w.logf("connection from %s: %v", c.RemoteAddr(), err)
+			}
+			mu.Lock()
+			delete(conns, c)
+			mu.Unlock()
+			c.Close()
+		}()
+	}
+}
+
+// serveConn writes the lines read from c to w until c is closed.
+func serveConn(c net.Conn, w *Writer) error {
+	br := bufio.NewReaderSize(c, maxLine)
+	for {
+		line, err := br.ReadSlice('\n')
+		if len(line) > 0 {
+			if _, werr := w.Write(line); werr != nil {
+				return werr
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			if err == io.EOF || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// listenFDsStart is the first file descriptor passed by systemd.
+const listenFDsStart = 3
+
+// SystemdListeners returns the listening sockets passed by systemd
+// socket activation, as described by the LISTEN_PID and LISTEN_FDS
+// environment variables, so a daemon can be started on the first
+// connection and restarted without its socket ever being closed.  It
+// returns nil if the process was not socket activated.  The
+// variables are unset, so child processes do not inherit them.
+func SystemdListeners() ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	var ls []net.Listener
+	for fd := listenFDsStart; fd < listenFDsStart+n; fd++ {
+		// FileListener makes its own copy of the descriptor
+		f := os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			for _, l := range ls {
+				l.Close()
+			}
+			return nil, fmt.Errorf("rotate: socket activation: fd %d: %w", fd, err)
+		}
+		ls = append(ls, l)
+	}
+	return ls, nil
+}
@@ -0,0 +1,93 @@
+package rotate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+)
+
+// WithSharedDir lets several processes write to the same root and
+// prefix.  Every write and rotation is done holding a lock on the
+// file prefix.lock in root, so each record lands whole in one file,
+// a process that finds the current file rotated by another moves on
+// to the new one, and archive names never collide.  It can not be
+// used with live compression.
+func WithSharedDir() Option {
+	return func(r *Writer) {
+		r.shared = true
+	}
+}
+
+// setupShared opens the lock file of a shared directory and takes
+// the lock, which the caller must release.
+func (r *Writer) setupShared() error {
+	if r.live != nil {
+		return errors.New("rotate: live compression can not be used in a shared directory")
+	}
+	if err := r.mkdirAll(r.root); err != nil {
+		return fmt.Errorf("rotate: create root: %w", err)
+	}
+	l, err := newFileLock(path.Join(r.root, r.prefix+".lock"), r.filePerm)
+	if err != nil {
+		return fmt.Errorf("rotate: open lock file: %w", err)
+	}
+	if err := r.fixFilePerm(l.f); err != nil {
+		l.close()
+		return fmt.Errorf("rotate: set permissions of lock file: %w", err)
+	}
+	if err := l.lock(); err != nil {
+		l.close()
+		return fmt.Errorf("rotate: lock: %w", err)
+	}
+	r.flock = l
+	return nil
+}
+
+// lockShared takes the lock of a shared directory, if it is one, and
+// catches up with the other processes: if one of them rotated the
+// current file, the new one is opened, and the size is brought up to
+// date with their writes.  The lock must be held.
+func (r *Writer) lockShared() error {
+	if r.flock == nil {
+		return nil
+	}
+	if err := r.flock.lock(); err != nil {
+		return fmt.Errorf("rotate: lock: %w", err)
+	}
+	if err := r.catchUp(); err != nil {
+		r.flock.unlock()
+		return err
+	}
+	return nil
+}
+
+func (r *Writer) catchUp() error {
+	cur, err := r.current.Stat()
+	if err != nil {
+		return fmt.Errorf("rotate: stat current file: %w", err)
+	}
+	if fi, err := os.Stat(r.currentPath()); err != nil || !os.SameFile(fi, cur) {
+		r.logf("%s was rotated by another process", r.currentPath())
+		r.closeCurrent()
+		if err := r.openCurrent(); err != nil {
+			return err
+		}
+		if cur, err = r.current.Stat(); err != nil {
+			return fmt.Errorf("rotate: stat current file: %w", err)
+		}
+	}
+	r.size = int(cur.Size())
+	return nil
+}
+
+// unlockShared releases the lock taken by lockShared.
+func (r *Writer) unlockShared() {
+	if r.flock != nil {
+		// the other processes must see all of it
+		if err := r.flush(); err != nil {
+			r.recordError(err)
+		}
+		r.flock.unlock()
+	}
+}
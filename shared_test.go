@@ -0,0 +1,78 @@
+package rotate
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestSharedDir(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	if _, err := New(root, "mt", WithSharedDir(), WithLiveCompression(Gzip)); err == nil {
+		t.Error("live compression in a shared directory succeeded")
+	}
+
+	// separate Writers open the lock file separately, as separate
+	// processes would
+	var ws []*Writer
+	for i := 0; i < 2; i++ {
+		x, err := New(root, "mt", WithSharedDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		x.SetMax(40)
+		x.SetKeep(100)
+		ws = append(ws, x)
+	}
+	done := make(chan bool)
+	for i, x := range ws {
+		go func(i int, x *Writer) {
+			for j := 0; j < 50; j++ {
+				if _, err := fmt.Fprintf(x, "writer %d line %02d\n", i, j); err != nil {
+					t.Error(err)
+				}
+			}
+			done <- true
+		}(i, x)
+	}
+	<-done
+	<-done
+	for _, x := range ws {
+		x.Close()
+	}
+
+	infos, err := ioutil.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := 0
+	for _, fi := range infos {
+		if fi.Name() == "mt.lock" {
+			continue
+		}
+		b, err := ioutil.ReadFile(path.Join(root, fi.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range bytes.SplitAfter(b, newline) {
+			if len(line) == 0 {
+				continue
+			}
+			if len(line) != len("writer 0 line 00\n") {
+				t.Errorf("%s: broken line %q", fi.Name(), line)
+			}
+			lines++
+		}
+	}
+	if lines != 100 {
+		t.Errorf("%d lines in %d files, expected 100", lines, len(infos)-1)
+	}
+}
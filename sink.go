@@ -0,0 +1,175 @@
+package rotate
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ArchiveSink receives archives after they have been rotated, and
+// optionally before they are deleted, so they can be copied to
+// object storage, a remote syslog server, a message queue or
+// anywhere else.  See SetSinkEvents.
+type ArchiveSink interface {
+	Store(ctx context.Context, path string, info ArchiveInfo) error
+}
+
+// SinkFunc adapts an ordinary function to an ArchiveSink.
+type SinkFunc func(ctx context.Context, path string, info ArchiveInfo) error
+
+// Store calls f(ctx, path, info).
+func (f SinkFunc) Store(ctx context.Context, path string, info ArchiveInfo) error {
+	return f(ctx, path, info)
+}
+
+// SinkEvents selects when archives are passed to the sink.
+type SinkEvents int
+
+const (
+	// SinkAfterRotation stores every archive right after it is
+	// rotated, from a separate goroutine, so a slow sink does not
+	// block writes.  This is the default.
+	SinkAfterRotation SinkEvents = 1 << iota
+
+	// SinkBeforeDeletion stores every archive before retention
+	// deletes it.  The sink is called synchronously, blocking
+	// writes, and an archive it fails to store is kept until the
+	// next clean up.
+	SinkBeforeDeletion
+)
+
+// sinkHolder lets a nil ArchiveSink be stored in an atomic.Value.
+type sinkHolder struct {
+	s ArchiveSink
+}
+
+// HTTPSink is an ArchiveSink that uploads each archive to a remote
+// collector with a chunked HTTP PUT to URL/<archive name>.  The
+// archive is streamed from disk, so it is never held in memory.
+type HTTPSink struct {
+	// URL is the base URL of the collector.
+	URL string
+
+	// Client is used to send the requests.  If nil,
+	// http.DefaultClient is used.
+	Client *http.Client
+
+	// Auth, if set, is called with every request before it is
+	// sent, so it can add credentials or sign the request.
+	Auth func(req *http.Request) error
+}
+
+// Store uploads the archive at path.
+func (s *HTTPSink) Store(ctx context.Context, path string, info ArchiveInfo) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	u := strings.TrimSuffix(s.URL, "/") + "/" + (&url.URL{Path: info.Name}).EscapedPath()
+	req, err := http.NewRequest(http.MethodPut, u, f)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	// an unknown length makes the transport use chunked encoding
+	req.ContentLength = -1
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("X-Rotate-Counter", strconv.Itoa(info.Counter))
+	req.Header.Set("X-Rotate-Size", strconv.FormatInt(info.Size, 10))
+	if s.Auth != nil {
+		if err := s.Auth(req); err != nil {
+			return err
+		}
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	ioutil.ReadAll(resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("rotate: upload of %s failed: %s", info.Name, resp.Status)
+	}
+	return nil
+}
+
+// SetSink sets the sink that archives are sent to.  A nil sink
+// disables it.
+func (r *Writer) SetSink(s ArchiveSink) {
+	r.Lock()
+	r.sink = s
+	r.storeDeleteSink()
+	r.Unlock()
+}
+
+// SetSinkEvents sets when archives are passed to the sink.
+func (r *Writer) SetSinkEvents(e SinkEvents) {
+	r.Lock()
+	r.sinkEvents = e
+	r.storeDeleteSink()
+	r.Unlock()
+}
+
+// storeDeleteSink publishes the sink to call before deleting an
+// archive, which a Coordinator uses without the lock.  The lock must
+// be held.
+func (r *Writer) storeDeleteSink() {
+	var h sinkHolder
+	if r.sinkEvents&SinkBeforeDeletion != 0 {
+		h.s = r.sink
+	}
+	r.delSink.Store(h)
+}
+
+// storeBeforeDelete stores a in the sink if it must be before it is
+// deleted.  It does not need the lock.
+func (r *Writer) storeBeforeDelete(a ArchiveInfo) error {
+	if h, ok := r.delSink.Load().(sinkHolder); ok && h.s != nil {
+		if err := h.s.Store(context.Background(), a.Path, a); err != nil {
+			return fmt.Errorf("store %s in sink before deleting it: %w", a.Name, err)
+		}
+	}
+	return nil
+}
+
+// SetErrorHandler sets a function that is called with errors that
+// happen in the background, such as a failed upload to the sink.
+func (r *Writer) SetErrorHandler(fn func(error)) {
+	r.Lock()
+	r.onError = fn
+	r.Unlock()
+}
+
+// store hands the archive to the sink in a new goroutine.  Close
+// waits for all of them to finish.
+func (r *Writer) store(info ArchiveInfo) {
+	if r.sink == nil || r.sinkEvents&SinkAfterRotation == 0 {
+		return
+	}
+	sink, onError := r.sink, r.onError
+	r.pending.Add(1)
+	goLabeled("sink", r.prefix, func() {
+		defer r.pending.Done()
+		var err error
+		region("sink", func() { err = sink.Store(context.Background(), info.Path, info) })
+		if err != nil {
+			err = fmt.Errorf("rotate: store %s in sink: %w", info.Name, err)
+			r.recordError(err)
+			if onError != nil {
+				onError(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package rotate
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"sync"
+	"testing"
+)
+
+func TestHTTPSink(t *testing.T) {
+	var mu sync.Mutex
+	got := make(map[string]string)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		mu.Lock()
+		got[req.URL.Path] = string(body)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(5)
+	x.SetSink(&HTTPSink{
+		URL: srv.URL + "/logs",
+		Auth: func(req *http.Request) error {
+			req.Header.Set("Authorization", "Bearer secret")
+			return nil
+		},
+	})
+	x.SetErrorHandler(func(err error) { t.Error(err) })
+	for i := 0; i < 3; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("uploads: %d, expected 3", len(got))
+	}
+	if got["/logs/mt_1"] != "hello\n" {
+		t.Errorf("mt_1: %q, expected %q", got["/logs/mt_1"], "hello\n")
+	}
+}
+
+func TestSinkBeforeDeletion(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored []string
+	fail := false
+	x.SetSink(SinkFunc(func(ctx context.Context, p string, info ArchiveInfo) error {
+		if fail {
+			return errors.New("collector down")
+		}
+		stored = append(stored, info.Name)
+		return nil
+	}))
+	x.SetSinkEvents(SinkBeforeDeletion)
+	x.SetMax(5)
+	x.SetKeep(1)
+	for i := 0; i < 3; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(stored) != 2 || stored[0] != "mt_1" || stored[1] != "mt_2" {
+		t.Errorf("stored %v, expected only the deleted mt_1 and mt_2", stored)
+	}
+
+	fail = true
+	if _, err := x.Write([]byte("hello\n")); err == nil {
+		t.Error("deleting with a failing sink succeeded")
+	}
+	if _, err := os.Stat(path.Join(root, "mt_3")); err != nil {
+		t.Errorf("archive the sink failed to store: %v", err)
+	}
+	x.Close()
+}
@@ -0,0 +1,87 @@
+package rotate
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits are the units ParseSize knows, in lower case.
+var sizeUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+// ParseSize parses a size in bytes such as "100MB" or "1.5GiB", for
+// sizes read from configuration files.  The units are B, the SI units
+// kB, MB, GB and TB, which are powers of 1000, and the binary units
+// KiB, MiB, GiB and TiB, which are powers of 1024.  Case is ignored,
+// and a number without a unit is in bytes.
+func ParseSize(s string) (int64, error) {
+	t := strings.TrimSpace(s)
+	i := strings.IndexFunc(t, func(c rune) bool {
+		return (c < '0' || c > '9') && c != '.'
+	})
+	if i < 0 {
+		i = len(t)
+	}
+	num, unit := t[:i], strings.ToLower(strings.TrimSpace(t[i:]))
+	mult, ok := sizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("rotate: bad size %q: unknown unit %q", s, t[i:])
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("rotate: bad size %q", s)
+	}
+	f = math.Round(f * mult)
+	if f >= math.MaxInt64 {
+		return 0, fmt.Errorf("rotate: bad size %q: too large", s)
+	}
+	return int64(f), nil
+}
+
+// parseMax parses s as the maximum size of a file.
+func parseMax(s string) (int, error) {
+	n, err := ParseSize(s)
+	if err != nil {
+		return 0, err
+	}
+	if n > math.MaxInt {
+		return 0, fmt.Errorf("rotate: bad size %q: too large", s)
+	}
+	return int(n), nil
+}
+
+// SetMaxString sets the maximum size for a file from a string such
+// as "100MB", as parsed by ParseSize.
+func (r *Writer) SetMaxString(s string) error {
+	n, err := parseMax(s)
+	if err != nil {
+		return err
+	}
+	r.SetMax(n)
+	return nil
+}
+
+// WithMaxSize makes New use the maximum size s, as parsed by
+// ParseSize.
+func WithMaxSize(s string) Option {
+	return func(r *Writer) {
+		n, err := parseMax(s)
+		if err != nil {
+			r.optErr = err
+			return
+		}
+		r.max = n
+	}
+}
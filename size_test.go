@@ -0,0 +1,63 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	for _, c := range []struct {
+		s    string
+		want int64
+	}{
+		{"100", 100},
+		{"100B", 100},
+		{"100MB", 100e6},
+		{"100 mb", 100e6},
+		{"1.5GiB", 3 << 29},
+		{"8MiB", 8 << 20},
+		{"2kB", 2000},
+		{" 1TiB ", 1 << 40},
+	} {
+		n, err := ParseSize(c.s)
+		if err != nil {
+			t.Errorf("%q: %v", c.s, err)
+			continue
+		}
+		if n != c.want {
+			t.Errorf("%q: %d, expected %d", c.s, n, c.want)
+		}
+	}
+	for _, s := range []string{"", "MB", "10 XB", "1.2.3MB", "-5", "1e30TB"} {
+		if _, err := ParseSize(s); err == nil {
+			t.Errorf("%q: no error", s)
+		}
+	}
+}
+
+func TestMaxSize(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	if _, err := New(root, "mt", WithMaxSize("lots")); err == nil {
+		t.Error("New with a bad size succeeded")
+	}
+	x, err := New(root, "mt", WithMaxSize("1KiB"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	if m := x.Options().Max; m != 1024 {
+		t.Errorf("max %d", m)
+	}
+	if err := x.SetMaxString("2kB"); err != nil {
+		t.Fatal(err)
+	}
+	if m := x.Options().Max; m != 2000 {
+		t.Errorf("max %d", m)
+	}
+}
@@ -0,0 +1,48 @@
+package rotate
+
+import (
+	"fmt"
+	"time"
+)
+
+// SetSizeCheck makes the Writer stat the current file at most once
+// every d, on a write, and take the file's size if it is larger than
+// the Writer counted, so that data appended by another process
+// writing to the same path counts toward the maximum size.  Zero, the
+// default, turns it off.  It is not needed with WithSharedDir, which
+// does so on every write, and has no effect with live compression.
+func (r *Writer) SetSizeCheck(d time.Duration) {
+	r.Lock()
+	r.sizeCheck = d
+	r.Unlock()
+}
+
+// checkSize brings the size up to date with the current file, if
+// SetSizeCheck asks for it and it is time.  The lock must be held.
+func (r *Writer) checkSize() {
+	if r.sizeCheck <= 0 || r.enc != nil || r.flock != nil || r.passthrough || r.discard {
+		return
+	}
+	now := time.Now()
+	if now.Sub(r.sizeChecked) < r.sizeCheck {
+		return
+	}
+	r.sizeChecked = now
+	fi, err := r.current.Stat()
+	if err != nil {
+		r.recordError(fmt.Errorf("rotate: stat current file: %w", err))
+		return
+	}
+	n := int(fi.Size())
+	if r.buf != nil {
+		n += r.buf.Buffered()
+	}
+	if n <= r.size {
+		return
+	}
+	r.logf("%s holds %d bytes more than written by this Writer", r.currentPath(), n-r.size)
+	r.size = n
+	if s, ok := r.trigger.(sizedTrigger); ok {
+		s.setSize(int64(n))
+	}
+}
@@ -0,0 +1,46 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestSizeCheck(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	x.SetMax(20)
+	x.SetSizeCheck(time.Nanosecond)
+
+	// another process appends to the same file
+	f, err := os.OpenFile(path.Join(root, fileDefault), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("from elsewhere\n")); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path.Join(root, "mt_1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "from elsewhere\nhello\n" {
+		t.Errorf("archive: %q", b)
+	}
+}
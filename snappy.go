@@ -0,0 +1,352 @@
+package rotate
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+)
+
+// Snappy is a Compressor using the snappy framing format, for
+// devices where gzip costs too much CPU but some compression is
+// still worth having.  Its files carry the .sz extension and can be
+// read by other snappy implementations.
+var Snappy Compressor = snappyCompressor{}
+
+func init() {
+	RegisterCompressor("snappy", Snappy)
+}
+
+type snappyCompressor struct{}
+
+func (snappyCompressor) Ext() string { return ".sz" }
+
+func (snappyCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return &snappyWriter{w: w, buf: make([]byte, 0, snappyChunk)}, nil
+}
+
+func (snappyCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return &snappyReader{r: r}, nil
+}
+
+const (
+	// snappyChunk is the most uncompressed data in a chunk.
+	snappyChunk = 65536
+
+	snappyCompressed   = 0x00
+	snappyUncompressed = 0x01
+	snappyPadding      = 0xfe
+	snappyStreamID     = 0xff
+
+	snappyMagic = "sNaPpY"
+)
+
+var errSnappyCorrupt = errors.New("rotate: corrupt snappy data")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// snappyCRC returns the masked checksum of p the framing format asks
+// for.
+func snappyCRC(p []byte) uint32 {
+	c := crc32.Checksum(p, castagnoli)
+	return (c>>15 | c<<17) + 0xa282ead8
+}
+
+// snappyWriter compresses to w a chunk at a time.
+type snappyWriter struct {
+	w       io.Writer
+	buf     []byte
+	started bool
+	out     []byte
+	err     error
+}
+
+func (s *snappyWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		if s.err != nil {
+			return n, s.err
+		}
+		m := copy(s.buf[len(s.buf):cap(s.buf)], p)
+		s.buf = s.buf[:len(s.buf)+m]
+		p = p[m:]
+		n += m
+		if len(s.buf) == cap(s.buf) {
+			s.err = s.flush()
+		}
+	}
+	return n, s.err
+}
+
+// flush writes the buffered data as a chunk.
+func (s *snappyWriter) flush() error {
+	if len(s.buf) == 0 {
+		return nil
+	}
+	s.out = s.out[:0]
+	if !s.started {
+		s.out = append(s.out, snappyStreamID, byte(len(snappyMagic)), 0, 0)
+		s.out = append(s.out, snappyMagic...)
+		s.started = true
+	}
+	hdr := len(s.out)
+	s.out = append(s.out, snappyCompressed, 0, 0, 0, 0, 0, 0, 0)
+	s.out = binary.LittleEndian.AppendUint32(s.out[:hdr+4], snappyCRC(s.buf))
+	s.out = encodeSnappy(s.out, s.buf)
+	if len(s.out)-hdr-8 >= len(s.buf) {
+		// not worth it
+		s.out = append(s.out[:hdr+8], s.buf...)
+		s.out[hdr] = snappyUncompressed
+	}
+	n := len(s.out) - hdr - 4
+	s.out[hdr+1], s.out[hdr+2], s.out[hdr+3] = byte(n), byte(n>>8), byte(n>>16)
+	s.buf = s.buf[:0]
+	_, err := s.w.Write(s.out)
+	return err
+}
+
+// Close writes the rest of the data, but does not close the
+// underlying writer.
+func (s *snappyWriter) Close() error {
+	if s.err != nil {
+		return s.err
+	}
+	s.err = s.flush()
+	if s.err == nil && !s.started {
+		// an empty stream is still a stream
+		s.out = append(s.out[:0], snappyStreamID, byte(len(snappyMagic)), 0, 0)
+		s.out = append(s.out, snappyMagic...)
+		s.started = true
+		_, s.err = s.w.Write(s.out)
+	}
+	if s.err == nil {
+		s.err = errors.New("rotate: snappy writer closed")
+		return nil
+	}
+	return s.err
+}
+
+// snappyReader decompresses a stream, or several concatenated.
+type snappyReader struct {
+	r       io.Reader
+	started bool
+	hdr     [4]byte
+	in, out []byte
+	err     error
+}
+
+func (s *snappyReader) Read(p []byte) (int, error) {
+	for len(s.out) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		s.err = s.next()
+	}
+	n := copy(p, s.out)
+	s.out = s.out[n:]
+	return n, nil
+}
+
+// next reads the next chunk.
+func (s *snappyReader) next() error {
+	if _, err := io.ReadFull(s.r, s.hdr[:]); err != nil {
+		if err == io.EOF && s.started {
+			return io.EOF
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return errSnappyCorrupt
+		}
+		return err
+	}
+	typ := s.hdr[0]
+	n := int(s.hdr[1]) | int(s.hdr[2])<<8 | int(s.hdr[3])<<16
+	if cap(s.in) < n {
+		s.in = make([]byte, n)
+	}
+	s.in = s.in[:n]
+	if _, err := io.ReadFull(s.r, s.in); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return errSnappyCorrupt
+		}
+		return err
+	}
+	if typ == snappyStreamID {
+		// also where concatenated streams meet
+		if string(s.in) != snappyMagic {
+			return errSnappyCorrupt
+		}
+		s.started = true
+		return nil
+	}
+	if !s.started {
+		return errSnappyCorrupt
+	}
+	switch {
+	case typ == snappyCompressed || typ == snappyUncompressed:
+		if n < 4 {
+			return errSnappyCorrupt
+		}
+		crc := binary.LittleEndian.Uint32(s.in)
+		data := s.in[4:]
+		if typ == snappyCompressed {
+			var err error
+			if data, err = decodeSnappy(data); err != nil {
+				return err
+			}
+		}
+		if len(data) > snappyChunk || snappyCRC(data) != crc {
+			return errSnappyCorrupt
+		}
+		s.out = data
+		return nil
+	case typ >= 0x80:
+		// padding and skippable chunks
+		return nil
+	}
+	return errSnappyCorrupt
+}
+
+func (s *snappyReader) Close() error { return nil }
+
+// encodeSnappy appends src, of at most snappyChunk bytes, compressed
+// in the snappy block format to dst.
+func encodeSnappy(dst, src []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(src)))
+	if len(src) < 16 {
+		return appendLiteral(dst, src)
+	}
+	const tableBits = 14
+	var table [1 << tableBits]int32 // positions + 1
+	hash := func(u uint32) uint32 { return u * 0x1e35a7bd >> (32 - tableBits) }
+
+	lit, s := 0, 0
+	for s+4 <= len(src) {
+		u := binary.LittleEndian.Uint32(src[s:])
+		h := hash(u)
+		cand := int(table[h]) - 1
+		table[h] = int32(s + 1)
+		if cand < 0 || binary.LittleEndian.Uint32(src[cand:]) != u {
+			// skip ahead faster through data that does not
+			// compress
+			s += 1 + (s-lit)>>5
+			continue
+		}
+		dst = appendLiteral(dst, src[lit:s])
+		n := 4
+		for s+n < len(src) && src[cand+n] == src[s+n] {
+			n++
+		}
+		dst = appendCopy(dst, s-cand, n)
+		s += n
+		lit = s
+	}
+	return appendLiteral(dst, src[lit:])
+}
+
+func appendLiteral(dst, lit []byte) []byte {
+	if len(lit) == 0 {
+		return dst
+	}
+	n := len(lit) - 1
+	switch {
+	case n < 60:
+		dst = append(dst, byte(n)<<2)
+	case n < 1<<8:
+		dst = append(dst, 60<<2, byte(n))
+	default:
+		dst = append(dst, 61<<2, byte(n), byte(n>>8))
+	}
+	return append(dst, lit...)
+}
+
+// appendCopy appends a copy of n bytes from offset bytes back, which
+// is less than snappyChunk.
+func appendCopy(dst []byte, offset, n int) []byte {
+	for n > 0 {
+		m := n
+		if m > 64 {
+			m = 64
+			if n-m < 4 {
+				// leave enough for a short copy
+				m = 60
+			}
+		}
+		if m >= 4 && m <= 11 && offset < 2048 {
+			dst = append(dst, byte(offset>>8)<<5|byte(m-4)<<2|1, byte(offset))
+		} else {
+			dst = append(dst, byte(m-1)<<2|2, byte(offset), byte(offset>>8))
+		}
+		n -= m
+	}
+	return dst
+}
+
+// decodeSnappy decompresses src, a snappy block of at most
+// snappyChunk bytes.
+func decodeSnappy(src []byte) ([]byte, error) {
+	size, k := binary.Uvarint(src)
+	if k <= 0 || size > snappyChunk {
+		return nil, errSnappyCorrupt
+	}
+	src = src[k:]
+	dst := make([]byte, 0, size)
+	for len(src) > 0 {
+		tag := src[0]
+		var n, offset int
+		switch tag & 3 {
+		case 0:
+			n = int(tag >> 2)
+			src = src[1:]
+			if n >= 60 {
+				b := n - 59
+				if len(src) < b {
+					return nil, errSnappyCorrupt
+				}
+				n = 0
+				for i := b - 1; i >= 0; i-- {
+					n = n<<8 | int(src[i])
+				}
+				src = src[b:]
+			}
+			n++
+			if n > len(src) || len(dst)+n > int(size) {
+				return nil, errSnappyCorrupt
+			}
+			dst = append(dst, src[:n]...)
+			src = src[n:]
+			continue
+		case 1:
+			if len(src) < 2 {
+				return nil, errSnappyCorrupt
+			}
+			n = 4 + int(tag>>2&7)
+			offset = int(tag&0xe0)<<3 | int(src[1])
+			src = src[2:]
+		case 2:
+			if len(src) < 3 {
+				return nil, errSnappyCorrupt
+			}
+			n = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src[1:]))
+			src = src[3:]
+		case 3:
+			if len(src) < 5 {
+				return nil, errSnappyCorrupt
+			}
+			n = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src[1:]))
+			src = src[5:]
+		}
+		if offset <= 0 || offset > len(dst) || len(dst)+n > int(size) {
+			return nil, errSnappyCorrupt
+		}
+		// the copy may overlap what it appends
+		for i := 0; i < n; i++ {
+			dst = append(dst, dst[len(dst)-offset])
+		}
+	}
+	if len(dst) != int(size) {
+		return nil, errSnappyCorrupt
+	}
+	return dst, nil
+}
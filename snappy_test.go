@@ -0,0 +1,102 @@
+package rotate
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path"
+	"testing"
+)
+
+func snappyRoundTrip(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	w, _ := Snappy.NewWriter(&buf)
+	// in pieces, across chunks
+	for p := data; len(p) > 0; {
+		n := 1000
+		if n > len(p) {
+			n = len(p)
+		}
+		if _, err := w.Write(p[:n]); err != nil {
+			t.Fatal(err)
+		}
+		p = p[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, _ := Snappy.NewReader(bytes.NewReader(buf.Bytes()))
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("round trip of %d bytes gave %d bytes", len(data), len(got))
+	}
+	return buf.Bytes()
+}
+
+func TestSnappy(t *testing.T) {
+	var logs bytes.Buffer
+	for i := 0; logs.Len() < 200000; i++ {
+		fmt.Fprintf(&logs, "2026-10-16T02:00:%02d level=info msg=\"request done\" id=%d status=200\n", i%60, i)
+	}
+	c := snappyRoundTrip(t, logs.Bytes())
+	if len(c) > logs.Len()/3 {
+		t.Errorf("logs compressed from %d to %d bytes", logs.Len(), len(c))
+	}
+
+	random := make([]byte, 100000)
+	rand.New(rand.NewSource(1)).Read(random)
+	snappyRoundTrip(t, random)
+	snappyRoundTrip(t, []byte("short"))
+	if c := snappyRoundTrip(t, nil); len(c) == 0 {
+		t.Error("empty stream has no stream identifier")
+	}
+	snappyRoundTrip(t, bytes.Repeat([]byte{'a'}, 70000))
+
+	// concatenated streams, as after a restart
+	one := snappyRoundTrip(t, []byte("hello\n"))
+	r, _ := Snappy.NewReader(bytes.NewReader(append(append([]byte(nil), one...), one...)))
+	if b, err := ioutil.ReadAll(r); err != nil || string(b) != "hello\nhello\n" {
+		t.Errorf("concatenated: %q, %v", b, err)
+	}
+
+	// a damaged chunk is caught by its checksum
+	bad := append([]byte(nil), c...)
+	bad[len(bad)/2] ^= 0xff
+	r, _ = Snappy.NewReader(bytes.NewReader(bad))
+	if _, err := ioutil.ReadAll(r); err == nil {
+		t.Error("no error for damaged data")
+	}
+}
+
+func TestSnappyLive(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt", WithLiveCompression(Snappy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path.Join(root, fileDefault+".sz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, _ := Snappy.NewReader(f)
+	if b, err := ioutil.ReadAll(r); err != nil || string(b) != "hello\n" {
+		t.Errorf("got %q, %v", b, err)
+	}
+}
@@ -0,0 +1,24 @@
+package rotate
+
+// SetSoftLimit sets a function that is called once per current file
+// when its size reaches size bytes, a warning threshold below the
+// maximum, so operators can be alerted about an unexpectedly chatty
+// component before rotations start to churn.  fn is called with the
+// size at the time, with the Writer's lock held, so it must not use
+// the Writer.  A nil fn removes it.
+func (r *Writer) SetSoftLimit(size int, fn func(size int)) {
+	r.Lock()
+	r.softLimit = size
+	r.onSoftLimit = fn
+	r.Unlock()
+}
+
+// checkSoftLimit calls the soft limit function if the current file
+// has just reached the soft limit.  The lock must be held.
+func (r *Writer) checkSoftLimit() {
+	if r.onSoftLimit == nil || r.softFired || r.size < r.softLimit {
+		return
+	}
+	r.softFired = true
+	r.onSoftLimit(r.size)
+}
@@ -0,0 +1,34 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestSoftLimit(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	x.SetMax(20)
+	var warned []int
+	x.SetSoftLimit(10, func(size int) { warned = append(warned, size) })
+	for i := 0; i < 6; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the first file is rotated at 24 bytes, the second holds 12
+	if !reflect.DeepEqual(warned, []int{12, 12}) {
+		t.Errorf("warnings: %v, expected one at 12 bytes per file", warned)
+	}
+}
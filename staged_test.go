@@ -0,0 +1,42 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestStagedCurrent(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt", WithStagedCurrent())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	x.SetMax(10)
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path.Join(root, "."+fileDefault)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path.Join(root, fileDefault)); !os.IsNotExist(err) {
+		t.Errorf("current file visible: %v", err)
+	}
+	if _, err := x.Write([]byte("world\n")); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path.Join(root, "mt_1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hello\nworld\n" {
+		t.Errorf("archive: %q", b)
+	}
+}
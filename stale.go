@@ -0,0 +1,13 @@
+//go:build !plan9
+
+package rotate
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isStale reports whether err is an NFS stale file handle.
+func isStale(err error) bool {
+	return errors.Is(err, syscall.ESTALE)
+}
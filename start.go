@@ -0,0 +1,58 @@
+package rotate
+
+import "fmt"
+
+// StartBehavior is what New does with a current file that is not
+// empty, left by an earlier run.
+type StartBehavior int
+
+const (
+	// Append keeps writing at the end of the file.  This is the
+	// default.
+	Append StartBehavior = iota
+
+	// Truncate discards the file's contents.
+	Truncate
+
+	// RotateFirst rotates the file into an archive before the
+	// first write.
+	RotateFirst
+)
+
+// WithStartBehavior sets what New does with a current file that is
+// not empty.
+func WithStartBehavior(b StartBehavior) Option {
+	return func(r *Writer) {
+		r.start = b
+	}
+}
+
+// startCurrent applies the start behavior to the current file just
+// opened by New.  The lock need not be held.
+func (r *Writer) startCurrent() error {
+	if r.start == Append {
+		if r.trigger == nil && r.size > 0 && r.size >= r.max {
+			// full already
+			return r.rotate(ReasonStart)
+		}
+		return nil
+	}
+	fi, err := r.current.Stat()
+	if err != nil {
+		return fmt.Errorf("rotate: stat current file: %w", err)
+	}
+	if fi.Size() == 0 {
+		return nil
+	}
+	switch r.start {
+	case Truncate:
+		if err := r.current.Truncate(0); err != nil {
+			return fmt.Errorf("rotate: truncate current file: %w", err)
+		}
+		r.size = 0
+		r.logf("truncated %s (%d bytes)", r.currentPath(), fi.Size())
+	case RotateFirst:
+		return r.rotate(ReasonStart)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestStartBehavior(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	current := path.Join(root, "default.log")
+	for _, tc := range []struct {
+		b        StartBehavior
+		expected string
+	}{
+		{Append, "old\nnew\n"},
+		{Truncate, "new\n"},
+		{RotateFirst, "new\n"},
+	} {
+		if err := ioutil.WriteFile(current, []byte("old\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		x, err := New(root, "mt", WithStartBehavior(tc.b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := x.Write([]byte("new\n")); err != nil {
+			t.Fatal(err)
+		}
+		x.Close()
+		if b, _ := ioutil.ReadFile(current); string(b) != tc.expected {
+			t.Errorf("start behavior %d: %q, expected %q", tc.b, b, tc.expected)
+		}
+	}
+	if b, _ := ioutil.ReadFile(path.Join(root, "mt_1")); string(b) != "old\n" {
+		t.Errorf("archive rotated first: %q", b)
+	}
+}
+
+func TestAppendExistingSize(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	current := path.Join(root, "default.log")
+	if err := ioutil.WriteFile(current, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(6)
+	if _, err := x.Write([]byte("a\n")); err != nil {
+		t.Fatal(err)
+	}
+	x.Close()
+	if b, _ := ioutil.ReadFile(path.Join(root, "mt_1")); string(b) != "old\na\n" {
+		t.Errorf("archive: %q, expected the existing data counted toward max", b)
+	}
+}
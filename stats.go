@@ -0,0 +1,83 @@
+package rotate
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Stats are counters of what a Writer has done since it was created,
+// for admin APIs and debug endpoints.
+type Stats struct {
+	// Writes and BytesWritten count the writes to current files.
+	Writes       uint64 `json:"writes"`
+	BytesWritten uint64 `json:"bytes_written"`
+
+	// Rotations counts the current files rotated.
+	Rotations uint64 `json:"rotations"`
+
+	// Deleted counts the archives deleted by retention.
+	Deleted uint64 `json:"deleted"`
+
+	// Errors counts the errors recorded for Health.
+	Errors uint64 `json:"errors"`
+
+	// Size is the number of bytes in the current file, and Counter
+	// the number the next archive will be given.
+	Size    int `json:"size"`
+	Counter int `json:"counter"`
+
+	// LastRotation is when the current file was last rotated, or
+	// the zero time if it has not been.
+	LastRotation time.Time `json:"last_rotation"`
+}
+
+// stats holds the counters of Stats.  They are updated atomically, as
+// some are counted without the lock.
+type stats struct {
+	writes, bytes, rotations, deleted, errors uint64
+}
+
+// Stats returns the Writer's counters.
+func (r *Writer) Stats() Stats {
+	r.Lock()
+	s := Stats{Size: r.size, Counter: r.counter, LastRotation: r.lastRotation}
+	r.Unlock()
+	s.Writes = atomic.LoadUint64(&r.stats.writes)
+	s.BytesWritten = atomic.LoadUint64(&r.stats.bytes)
+	s.Rotations = atomic.LoadUint64(&r.stats.rotations)
+	s.Deleted = atomic.LoadUint64(&r.stats.deleted)
+	s.Errors = atomic.LoadUint64(&r.stats.errors)
+	return s
+}
+
+// countWrite counts a write of n bytes.
+func (r *Writer) countWrite(n int) {
+	atomic.AddUint64(&r.stats.writes, 1)
+	atomic.AddUint64(&r.stats.bytes, uint64(n))
+}
+
+// String returns the counters on one line, for logs.
+func (s Stats) String() string {
+	last := "never"
+	if !s.LastRotation.IsZero() {
+		last = s.LastRotation.Format(time.RFC3339)
+	}
+	return fmt.Sprintf("writes=%d bytes=%d rotations=%d deleted=%d errors=%d size=%d counter=%d last_rotation=%s",
+		s.Writes, s.BytesWritten, s.Rotations, s.Deleted, s.Errors, s.Size, s.Counter, last)
+}
+
+// MarshalJSON encodes s with the field names given by its tags, and
+// last_rotation as null if there has been no rotation.
+func (s Stats) MarshalJSON() ([]byte, error) {
+	type plain Stats
+	var last *time.Time
+	if !s.LastRotation.IsZero() {
+		last = &s.LastRotation
+	}
+	return json.Marshal(struct {
+		plain
+		LastRotation *time.Time `json:"last_rotation"`
+	}{plain(s), last})
+}
@@ -0,0 +1,46 @@
+package rotate
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	b, err := json.Marshal(x.Stats())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"writes":0,"bytes_written":0,"rotations":0,"deleted":0,"errors":0,"size":0,"counter":1,"last_rotation":null}`
+	if string(b) != want {
+		t.Errorf("got  %s\nwant %s", b, want)
+	}
+
+	x.SetMax(10)
+	x.SetKeep(1)
+	for i := 0; i < 6; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := x.Stats()
+	if s.Writes != 6 || s.BytesWritten != 36 || s.Rotations != 3 || s.Deleted != 2 || s.Counter != 4 {
+		t.Errorf("stats: %v", s)
+	}
+	if !strings.HasPrefix(s.String(), "writes=6 bytes=36 rotations=3 deleted=2 errors=0 size=0 counter=4 last_rotation=") {
+		t.Errorf("string: %s", s)
+	}
+}
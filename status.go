@@ -0,0 +1,67 @@
+package rotate
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// statusHeader is the first line of a logrotate state file.
+const statusHeader = "logrotate state -- version 2"
+
+// statusMu serializes updates of status files, which several
+// Writers may share.
+var statusMu sync.Mutex
+
+// SetStatusFile makes the Writer record the time of every rotation
+// in the file at p, in the format of logrotate's state file, so that
+// tooling that monitors logrotate keeps working during a migration.
+// Several Writers may share the file; each updates the line for its
+// own current file and keeps the others.  An empty p turns it off,
+// which is the default.
+func (r *Writer) SetStatusFile(p string) {
+	r.Lock()
+	r.statusFile = p
+	r.Unlock()
+}
+
+// writeStatus records that the current file was rotated at t.  The
+// lock must be held.
+func (r *Writer) writeStatus(t time.Time) error {
+	if r.statusFile == "" {
+		return nil
+	}
+	log, err := filepath.Abs(r.currentPath())
+	if err != nil {
+		return err
+	}
+	statusMu.Lock()
+	defer statusMu.Unlock()
+
+	var buf bytes.Buffer
+	buf.WriteString(statusHeader + "\n")
+	quoted := fmt.Sprintf("%q", log)
+	if data, err := ioutil.ReadFile(r.statusFile); err == nil {
+		sc := bufio.NewScanner(bytes.NewReader(data))
+		for sc.Scan() {
+			line := sc.Text()
+			if line == statusHeader || line == "" || strings.HasPrefix(line, quoted+" ") {
+				continue
+			}
+			buf.WriteString(line + "\n")
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	fmt.Fprintf(&buf, "%s %d-%d-%d-%d:%d:%d\n", quoted, t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second())
+	if err := writeFileAtomic(r.statusFile, buf.Bytes(), r.filePerm); err != nil {
+		return err
+	}
+	return r.fixPerm(r.statusFile)
+}
@@ -0,0 +1,47 @@
+package rotate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStatusFile(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	status := path.Join(root, "status")
+	other := `"/var/log/syslog" 2024-1-2-3:4:5` + "\n"
+	if err := ioutil.WriteFile(status, []byte(statusHeader+"\n"+other), 0644); err != nil {
+		t.Fatal(err)
+	}
+	x, err := New(path.Join(root, "a"), "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(1)
+	x.SetStatusFile(status)
+	for i := 0; i < 2; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	x.Close()
+
+	b, err := ioutil.ReadFile(status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	log, _ := filepath.Abs(path.Join(root, "a", "default.log"))
+	if len(lines) != 3 || lines[0] != statusHeader || lines[1]+"\n" != other || !strings.HasPrefix(lines[2], fmt.Sprintf("%q ", log)) {
+		t.Errorf("status file:\n%s", b)
+	}
+}
@@ -0,0 +1,47 @@
+package rotate
+
+import (
+	"os"
+	"runtime"
+)
+
+// SetSyncDirs sets whether the directories involved are synced
+// after an archive is renamed or removed, so the change survives a
+// crash or power loss.  This makes rotation slower, but matters for
+// audit logs.
+func (r *Writer) SetSyncDirs(on bool) {
+	r.Lock()
+	defer r.Unlock()
+	lay := *r.layout()
+	lay.syncDirs = on
+	r.lay.Store(&lay)
+}
+
+// syncDirs fsyncs each of dirs once.  Directories can not be synced
+// on Windows, where it does nothing.
+func syncDirs(dirs ...string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	done := make(map[string]bool)
+	for _, dir := range dirs {
+		if done[dir] {
+			continue
+		}
+		done[dir] = true
+		d, err := os.Open(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// removed as it became empty
+				continue
+			}
+			return err
+		}
+		err = d.Sync()
+		d.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
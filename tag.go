@@ -0,0 +1,58 @@
+package rotate
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+)
+
+// TagCurrent tags the current file with key set to value, so tooling
+// can later tell its archive apart, as with "incident" set to
+// "1234".  The tags are recorded in the archive's metadata when the
+// file is rotated, and apply to that archive only.  An empty value
+// removes the tag.  Tags are only recorded if SetSidecar is on.
+func (r *Writer) TagCurrent(key, value string) {
+	r.Lock()
+	defer r.Unlock()
+	r.tags = setTag(r.tags, key, value)
+}
+
+// TagArchive tags the archive named name, as in ArchiveInfo.Name,
+// with key set to value, as when a shipper marks it "uploaded".  An
+// empty value removes the tag.  The archive must have metadata,
+// written when SetSidecar is on.
+func (r *Writer) TagArchive(name, key, value string) error {
+	r.Lock()
+	defer r.Unlock()
+	p := path.Join(r.archiveRoot(), name)
+	meta := readMeta(p)
+	if meta == nil {
+		return fmt.Errorf("rotate: tag %s: no metadata", name)
+	}
+	meta.Tags = setTag(meta.Tags, key, value)
+	data, err := json.MarshalIndent(meta, "", "\t")
+	if err != nil {
+		return fmt.Errorf("rotate: tag %s: %w", name, err)
+	}
+	if err := writeFileAtomic(p+metaSuffix, append(data, '\n'), r.filePerm); err != nil {
+		return fmt.Errorf("rotate: tag %s: %w", name, err)
+	}
+	return r.fixPerm(p + metaSuffix)
+}
+
+// setTag sets key to value in tags, or removes it if value is empty,
+// and returns tags, which is allocated if needed and nil if empty.
+func setTag(tags map[string]string, key, value string) map[string]string {
+	if value == "" {
+		delete(tags, key)
+		if len(tags) == 0 {
+			return nil
+		}
+		return tags
+	}
+	if tags == nil {
+		tags = make(map[string]string)
+	}
+	tags[key] = value
+	return tags
+}
@@ -0,0 +1,59 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	x.SetMax(6)
+	x.SetSidecar(true)
+	x.TagCurrent("incident", "1234")
+	for i := 0; i < 2; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if a, _ := x.Archives(); len(a) != 2 || a[0].Meta == nil || a[0].Meta.Tags["incident"] != "1234" || a[1].Meta.Tags != nil {
+		t.Errorf("tags at rotation: %+v", a)
+	}
+	if err := x.TagArchive("mt_2", "uploaded", "yes"); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.TagArchive("mt_1", "incident", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.TagArchive("mt_9", "uploaded", "yes"); err == nil {
+		t.Error("tagged a missing archive")
+	}
+
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 2 || archives[0].Meta == nil || archives[1].Meta == nil {
+		t.Fatalf("archives: %+v", archives)
+	}
+	if tags := archives[0].Meta.Tags; tags != nil {
+		t.Errorf("mt_1 tags: %v", tags)
+	}
+	if tags := archives[1].Meta.Tags; !reflect.DeepEqual(tags, map[string]string{"uploaded": "yes"}) {
+		t.Errorf("mt_2 tags: %v", tags)
+	}
+	if archives[1].Meta.Lines != 1 {
+		t.Errorf("mt_2 metadata lost: %+v", archives[1].Meta)
+	}
+}
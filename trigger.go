@@ -0,0 +1,235 @@
+package rotate
+
+import (
+	"bytes"
+	"math/rand"
+	"time"
+)
+
+// A Trigger decides when the current file is rotated.  It is told
+// when a new current file is opened and is fed every write to it,
+// after the write, so a record is never split across files.
+// Triggers are called with the Writer's lock held, so they may keep
+// state without locking, but one must not be shared by two Writers.
+type Trigger interface {
+	// Reset is called when a current file is opened at t.
+	Reset(t time.Time)
+
+	// Written is called after p has been written to the current
+	// file at t, and reports whether it should be rotated now.
+	Written(p []byte, t time.Time) bool
+}
+
+// SetTrigger sets the Trigger that decides when to rotate, in place
+// of the maximum size set by SetMax.  A nil Trigger goes back to the
+// maximum size.
+func (r *Writer) SetTrigger(t Trigger) {
+	r.Lock()
+	defer r.Unlock()
+	r.trigger = t
+	if t != nil {
+		r.resetTrigger()
+	}
+}
+
+// sizedTrigger is a Trigger that counts bytes, and so must know how
+// much the current file held when it was opened.
+type sizedTrigger interface {
+	Trigger
+	setSize(n int64)
+}
+
+// resetTrigger tells the Trigger that the current file was opened.
+// The lock must be held.
+func (r *Writer) resetTrigger() {
+	r.trigger.Reset(r.opened)
+	if s, ok := r.trigger.(sizedTrigger); ok {
+		s.setSize(int64(r.size))
+	}
+}
+
+// written feeds p, just written to the current file, to the rotation
+// policy and reports whether the file must be rotated.  The lock
+// must be held.
+func (r *Writer) written(p []byte) bool {
+	if r.trigger != nil {
+		now := time.Now()
+		if !r.trigger.Written(p, now) {
+			return false
+		}
+		r.dueReason = triggerReason(r.trigger)
+		// rotations by age wait for the quiet window
+		return r.dueReason != ReasonTime || r.inWindow(now)
+	}
+	r.dueReason = ReasonSize
+	return r.size >= r.max
+}
+
+// SizeTrigger returns a Trigger that rotates once the current file
+// holds max bytes, counting what it held when it was opened.
+func SizeTrigger(max int64) Trigger {
+	return &sizeTrigger{max: max}
+}
+
+type sizeTrigger struct {
+	max, n int64
+}
+
+func (s *sizeTrigger) Reset(time.Time) { s.n = 0 }
+
+func (s *sizeTrigger) setSize(n int64) { s.n = n }
+
+func (s *sizeTrigger) Written(p []byte, _ time.Time) bool {
+	s.n += int64(len(p))
+	return s.n >= s.max
+}
+
+func (s *sizeTrigger) reason() RotationReason { return ReasonSize }
+
+// IntervalTrigger returns a Trigger that rotates at the first write
+// once the current file has been open for d.  A Writer that is not
+// written to is not rotated.
+func IntervalTrigger(d time.Duration) Trigger {
+	return &intervalTrigger{d: d}
+}
+
+// JitteredIntervalTrigger is like IntervalTrigger, but each file is
+// kept open for d plus a random duration of up to jitter, so a large
+// fleet of Writers started together does not rotate, compress and
+// upload all at once, overloading shared collectors.
+func JitteredIntervalTrigger(d, jitter time.Duration) Trigger {
+	return &intervalTrigger{d: d, jitter: jitter}
+}
+
+type intervalTrigger struct {
+	d, jitter time.Duration
+	opened    time.Time
+	cur       time.Duration
+}
+
+func (i *intervalTrigger) Reset(t time.Time) {
+	i.opened = t
+	i.cur = i.d
+	if i.jitter > 0 {
+		i.cur += time.Duration(rand.Int63n(int64(i.jitter)))
+	}
+}
+
+func (i *intervalTrigger) Written(_ []byte, t time.Time) bool {
+	return t.Sub(i.opened) >= i.cur
+}
+
+func (i *intervalTrigger) reason() RotationReason { return ReasonTime }
+
+// LinesTrigger returns a Trigger that rotates once n newlines have
+// been written to the current file.
+func LinesTrigger(n int) Trigger {
+	return &linesTrigger{max: n}
+}
+
+type linesTrigger struct {
+	max, n int
+}
+
+func (l *linesTrigger) Reset(time.Time) { l.n = 0 }
+
+func (l *linesTrigger) Written(p []byte, _ time.Time) bool {
+	l.n += bytes.Count(p, newline)
+	return l.n >= l.max
+}
+
+func (l *linesTrigger) reason() RotationReason { return ReasonLines }
+
+// AnyTrigger returns a Trigger that rotates when any of ts would.
+// All of them see every write, so each keeps its own count.  This is
+// what most policies look like, such as 8 MiB or a day, whichever
+// comes first:
+//
+//	w.SetTrigger(rotate.AnyTrigger(
+//		rotate.SizeTrigger(8<<20),
+//		rotate.IntervalTrigger(24*time.Hour),
+//	))
+func AnyTrigger(ts ...Trigger) Trigger {
+	return &anyTrigger{ts: ts}
+}
+
+type anyTrigger struct {
+	ts []Trigger
+
+	// fired is the first of ts that fired on the last write, or
+	// the first that did not fire by age if there is one
+	fired Trigger
+}
+
+func (a *anyTrigger) Reset(t time.Time) {
+	for _, x := range a.ts {
+		x.Reset(t)
+	}
+}
+
+func (a *anyTrigger) setSize(n int64) {
+	for _, x := range a.ts {
+		if s, ok := x.(sizedTrigger); ok {
+			s.setSize(n)
+		}
+	}
+}
+
+func (a *anyTrigger) Written(p []byte, t time.Time) bool {
+	a.fired = nil
+	for _, x := range a.ts {
+		if !x.Written(p, t) {
+			continue
+		}
+		if a.fired == nil || triggerReason(a.fired) == ReasonTime {
+			a.fired = x
+		}
+	}
+	return a.fired != nil
+}
+
+func (a *anyTrigger) reason() RotationReason {
+	if a.fired == nil {
+		return ReasonTrigger
+	}
+	return triggerReason(a.fired)
+}
+
+// SetBeforeRotate sets a function that is called before every
+// rotation with the path and size of the current file.  If it
+// returns an error, the rotation is postponed: the write that called
+// for it succeeds, and the rotation is considered again after the
+// next write.  This keeps related records together, for example
+// while a batch of a transaction is being flushed.  fn is called
+// with the Writer's lock held, so it must not use the Writer.  A nil
+// fn removes it.
+func (r *Writer) SetBeforeRotate(fn func(path string, size int64) error) {
+	r.Lock()
+	r.beforeRotate = fn
+	r.Unlock()
+}
+
+// RotateOn rotates the current file, if it is not empty, every time
+// a value is received from ch, so any external event, such as a
+// configuration reload or an upgrade hook, can cause a rotation.  It
+// stops when ch is closed or the Writer is.  Errors are recorded for
+// Health.
+func (r *Writer) RotateOn(ch <-chan struct{}) {
+	goLabeled("rotate-on", r.prefix, func() {
+		for {
+			select {
+			case _, ok := <-ch:
+				if !ok {
+					return
+				}
+			case <-r.closing:
+				return
+			}
+			r.Lock()
+			if r.current != nil && r.size > 0 {
+				r.rotate(ReasonSignal)
+			}
+			r.Unlock()
+		}
+	})
+}
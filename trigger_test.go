@@ -0,0 +1,163 @@
+package rotate
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestTrigger(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetTrigger(AnyTrigger(LinesTrigger(2), SizeTrigger(12)))
+	for _, s := range []string{"a\n", "b\n", "a long line\n", "c\n"} {
+		if _, err := x.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if b, _ := ioutil.ReadFile(path.Join(root, "mt_1")); string(b) != "a\nb\n" {
+		t.Errorf("mt_1: %q, expected two lines", b)
+	}
+	if b, _ := ioutil.ReadFile(path.Join(root, "mt_2")); string(b) != "a long line\n" {
+		t.Errorf("mt_2: %q, expected 12 bytes", b)
+	}
+
+	x.SetTrigger(IntervalTrigger(20 * time.Millisecond))
+	if _, err := x.Write([]byte("d\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path.Join(root, "mt_3")); err == nil {
+		t.Error("rotated before the interval")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, err := x.Write([]byte("e\n")); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(path.Join(root, "mt_3")); string(b) != "c\nd\ne\n" {
+		t.Errorf("mt_3: %q, expected the writes of the interval", b)
+	}
+	x.Close()
+}
+
+func TestBeforeRotate(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(5)
+	inBatch := true
+	x.SetBeforeRotate(func(p string, size int64) error {
+		if p != path.Join(root, "default.log") || size < 5 {
+			t.Errorf("hook called with %s, %d", p, size)
+		}
+		if inBatch {
+			return errors.New("batch in progress")
+		}
+		return nil
+	})
+	for _, s := range []string{"begin\n", "end\n"} {
+		if _, err := x.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(path.Join(root, "mt_1")); err == nil {
+		t.Error("rotated during the batch")
+	}
+	inBatch = false
+	if _, err := x.Write([]byte("next\n")); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(path.Join(root, "mt_1")); string(b) != "begin\nend\nnext\n" {
+		t.Errorf("mt_1: %q, expected the whole batch", b)
+	}
+	x.Close()
+}
+
+func TestRotateOn(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan struct{})
+	x.RotateOn(ch)
+	ch <- struct{}{}
+	if _, err := x.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	ch <- struct{}{}
+	waitFor(t, "rotation", func() bool {
+		_, err := os.Stat(path.Join(root, "mt_1"))
+		return err == nil
+	})
+	if c := x.GetCounter(); c != 2 {
+		t.Errorf("counter: %d, expected 2 after one rotation of a non-empty file", c)
+	}
+	x.Close()
+}
+
+func TestJitteredIntervalTrigger(t *testing.T) {
+	tr := JitteredIntervalTrigger(time.Hour, time.Hour)
+	t0 := time.Now()
+	seen := make(map[bool]int)
+	for i := 0; i < 100; i++ {
+		tr.Reset(t0)
+		if tr.Written(nil, t0.Add(59*time.Minute)) {
+			t.Fatal("rotated before the interval")
+		}
+		if !tr.Written(nil, t0.Add(2*time.Hour)) {
+			t.Fatal("not rotated after the interval and jitter")
+		}
+		seen[tr.Written(nil, t0.Add(90*time.Minute))]++
+	}
+	if seen[true] == 0 || seen[false] == 0 {
+		t.Errorf("rotations at 90 minutes: %v, expected some of each", seen)
+	}
+}
+
+func TestAnyTriggerExistingSize(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	if err := ioutil.WriteFile(path.Join(root, fileDefault), []byte("earlier\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	// 12 bytes or an hour, whichever comes first
+	x.SetTrigger(AnyTrigger(SizeTrigger(12), IntervalTrigger(time.Hour)))
+	if _, err := x.Write([]byte("now\n")); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(path.Join(root, "mt_1")); string(b) != "earlier\nnow\n" {
+		t.Errorf("mt_1: %q, expected the earlier data counted toward the size", b)
+	}
+}
@@ -0,0 +1,298 @@
+package rotate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UploadOptions configures an UploadQueue.
+type UploadOptions struct {
+	// Concurrency is the most uploads in progress at once.  The
+	// default is 1.
+	Concurrency int
+
+	// MinBackoff is the delay before the first retry of a failed
+	// upload.  It doubles with every further failure, up to
+	// MaxBackoff.  The defaults are one second and five minutes.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// OnError, if set, is called with every failed attempt.
+	OnError func(error)
+}
+
+// An UploadQueue is an ArchiveSink that keeps the archives it is
+// given in a queue on disk and stores them in another sink, retrying
+// failures with exponential backoff.  Archives rotated while the
+// network is down are uploaded once it is back, even if the process
+// restarts in between.  An archive deleted by retention before it
+// could be uploaded is dropped from the queue.
+type UploadQueue struct {
+	dir  string
+	sink ArchiveSink
+	opts UploadOptions
+
+	mu     sync.Mutex
+	jobs   map[string]*uploadJob
+	seq    int
+	wake   chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+	active sync.WaitGroup
+}
+
+// uploadJob is an archive waiting in the queue.  It is saved as JSON
+// in the queue's directory.
+type uploadJob struct {
+	Path     string      `json:"path"`
+	Info     ArchiveInfo `json:"info"`
+	Attempts int         `json:"attempts"`
+	NextTry  time.Time   `json:"nextTry"`
+
+	name     string
+	inFlight bool
+}
+
+const uploadSuffix = ".upload.json"
+
+// NewUploadQueue creates an UploadQueue that keeps its queue in dir,
+// creating it if necessary, and uploads to sink.  Archives left in
+// the queue by an earlier run are retried right away.  Close stops
+// it.
+func NewUploadQueue(dir string, sink ArchiveSink, o UploadOptions) (*UploadQueue, error) {
+	if o.Concurrency <= 0 {
+		o.Concurrency = 1
+	}
+	if o.MinBackoff <= 0 {
+		o.MinBackoff = time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = 5 * time.Minute
+	}
+	if err := os.MkdirAll(dir, RootPerm); err != nil {
+		return nil, fmt.Errorf("rotate: create upload queue: %w", err)
+	}
+	q := &UploadQueue{
+		dir:  dir,
+		sink: sink,
+		opts: o,
+		jobs: make(map[string]*uploadJob),
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+	goLabeled("upload", "", func() { q.run(ctx) })
+	return q, nil
+}
+
+// Store adds the archive at path to the queue.  It returns once the
+// queue entry is on disk.
+func (q *UploadQueue) Store(ctx context.Context, path string, info ArchiveInfo) error {
+	q.mu.Lock()
+	q.seq++
+	j := &uploadJob{
+		Path:    path,
+		Info:    info,
+		NextTry: time.Now(),
+		name:    fmt.Sprintf("%d-%d%s", time.Now().UnixNano(), q.seq, uploadSuffix),
+	}
+	err := q.save(j)
+	if err == nil {
+		q.jobs[j.name] = j
+	}
+	q.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("rotate: queue upload of %s: %w", info.Name, err)
+	}
+	q.poke()
+	return nil
+}
+
+// Pending returns the number of archives waiting to be uploaded.
+func (q *UploadQueue) Pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.jobs)
+}
+
+// Close stops uploading, interrupting any uploads in progress.
+// Archives not yet uploaded stay queued on disk for the next
+// UploadQueue in the same directory.
+func (q *UploadQueue) Close() error {
+	q.cancel()
+	<-q.done
+	return nil
+}
+
+// load reads the queue left on disk.
+func (q *UploadQueue) load() error {
+	infos, err := ioutil.ReadDir(q.dir)
+	if err != nil {
+		return fmt.Errorf("rotate: read upload queue: %w", err)
+	}
+	for _, fi := range infos {
+		if !strings.HasSuffix(fi.Name(), uploadSuffix) {
+			continue
+		}
+		data, err := ioutil.ReadFile(path.Join(q.dir, fi.Name()))
+		if err != nil {
+			return fmt.Errorf("rotate: read upload queue: %w", err)
+		}
+		j := &uploadJob{name: fi.Name()}
+		if err := json.Unmarshal(data, j); err != nil {
+			return fmt.Errorf("rotate: read upload queue entry %s: %w", fi.Name(), err)
+		}
+		j.NextTry = time.Now()
+		q.jobs[j.name] = j
+	}
+	return nil
+}
+
+// save writes j to disk.  q.mu must be held.
+func (q *UploadQueue) save(j *uploadJob) error {
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path.Join(q.dir, j.name), data, FilePerm)
+}
+
+// poke wakes the scheduler.
+func (q *UploadQueue) poke() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run starts the uploads that are due, up to the concurrency limit,
+// and sleeps until the next one is.
+func (q *UploadQueue) run(ctx context.Context) {
+	defer close(q.done)
+	defer q.active.Wait()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		next := q.startDue(ctx)
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if !next.IsZero() {
+			timer.Reset(time.Until(next))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// startDue starts the due uploads and returns when the earliest of
+// the rest is due, or the zero time if none is waiting.
+func (q *UploadQueue) startDue(ctx context.Context) time.Time {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	inFlight := 0
+	for _, j := range q.jobs {
+		if j.inFlight {
+			inFlight++
+		}
+	}
+	now := time.Now()
+	var next time.Time
+	for _, j := range q.jobs {
+		if j.inFlight {
+			continue
+		}
+		if j.NextTry.After(now) || inFlight >= q.opts.Concurrency {
+			if next.IsZero() || j.NextTry.Before(next) {
+				next = j.NextTry
+			}
+			continue
+		}
+		j.inFlight = true
+		inFlight++
+		q.active.Add(1)
+		go q.upload(ctx, j)
+	}
+	return next
+}
+
+// upload stores j in the sink and removes it from the queue, or
+// schedules a retry.
+func (q *UploadQueue) upload(ctx context.Context, j *uploadJob) {
+	defer q.active.Done()
+	defer q.poke()
+	var err error
+	region("upload", func() { err = q.sink.Store(ctx, j.Path, j.Info) })
+	if err != nil && ctx.Err() != nil {
+		// interrupted by Close; leave it for the next run
+		return
+	}
+	if err != nil {
+		if _, serr := os.Stat(j.Path); os.IsNotExist(serr) {
+			err = fmt.Errorf("rotate: dropping upload of %s: %w", j.Info.Name, serr)
+		} else {
+			err = fmt.Errorf("rotate: upload of %s, attempt %d: %w", j.Info.Name, j.Attempts+1, err)
+			if serr := q.retry(j); serr != nil {
+				q.report(serr)
+			}
+			q.report(err)
+			return
+		}
+	}
+	q.mu.Lock()
+	delete(q.jobs, j.name)
+	os.Remove(path.Join(q.dir, j.name))
+	q.mu.Unlock()
+	if err != nil {
+		q.report(err)
+	}
+}
+
+// retry schedules j to be tried again after a backoff.
+func (q *UploadQueue) retry(j *uploadJob) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j.inFlight = false
+	j.Attempts++
+	j.NextTry = time.Now().Add(q.backoff(j.Attempts))
+	if err := q.save(j); err != nil {
+		return fmt.Errorf("rotate: save upload queue entry %s: %w", j.name, err)
+	}
+	return nil
+}
+
+// backoff returns the delay after the given number of failures.
+func (q *UploadQueue) backoff(attempts int) time.Duration {
+	d := q.opts.MinBackoff
+	for i := 1; i < attempts && d < q.opts.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > q.opts.MaxBackoff {
+		d = q.opts.MaxBackoff
+	}
+	return d
+}
+
+func (q *UploadQueue) report(err error) {
+	if q.opts.OnError != nil {
+		q.opts.OnError(err)
+	}
+}
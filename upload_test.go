@@ -0,0 +1,104 @@
+package rotate
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakySink fails until it is told to work.
+type flakySink struct {
+	mu     sync.Mutex
+	up     bool
+	tries  int
+	stored []string
+}
+
+func (s *flakySink) Store(ctx context.Context, path string, info ArchiveInfo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tries++
+	if !s.up {
+		return errors.New("network is down")
+	}
+	s.stored = append(s.stored, info.Name)
+	return nil
+}
+
+func (s *flakySink) state() (int, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tries, append([]string(nil), s.stored...)
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for i := 0; i < 500; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestUploadQueue(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	qdir := path.Join(root, "queue")
+
+	sink := &flakySink{}
+	q, err := NewUploadQueue(qdir, sink, UploadOptions{MinBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := New(path.Join(root, "logs"), "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(5)
+	x.SetSink(q)
+	for i := 0; i < 2; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "retries", func() bool {
+		tries, _ := sink.state()
+		return tries >= 4
+	})
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, stored := sink.state(); len(stored) != 0 {
+		t.Fatalf("stored %v while down", stored)
+	}
+
+	// a new queue picks up where the old one stopped
+	sink.mu.Lock()
+	sink.up = true
+	sink.mu.Unlock()
+	q, err = NewUploadQueue(qdir, sink, UploadOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	waitFor(t, "uploads", func() bool { return q.Pending() == 0 })
+	if _, stored := sink.state(); len(stored) != 2 {
+		t.Errorf("stored %v, expected mt_1 and mt_2", stored)
+	}
+	if names, _ := ioutil.ReadDir(qdir); len(names) != 0 {
+		t.Errorf("%d entries left in the queue directory", len(names))
+	}
+}
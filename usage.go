@@ -0,0 +1,103 @@
+package rotate
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+// Usage is the storage used by a Writer's logs.
+type Usage struct {
+	// Current is the size of the current file.
+	Current int64
+
+	// Archives is the total size of the archives on disk, and
+	// ArchiveCount their number.  This is what retention limits
+	// are measured against, so compressed archives count at their
+	// compressed size.
+	Archives     int64
+	ArchiveCount int
+
+	// ArchivesLogical is the total uncompressed size of the
+	// archives.  It is only computed by LogicalDiskUsage.
+	ArchivesLogical int64
+
+	// Free is the space available on the filesystem that holds
+	// the archives, or -1 if the platform cannot tell.
+	Free int64
+}
+
+// Total returns the bytes used by the current file and the archives.
+func (u Usage) Total() int64 {
+	return u.Current + u.Archives
+}
+
+// DiskUsage returns the storage used by the current file and the
+// archives, and the free space left, for display in a user
+// interface.
+func (r *Writer) DiskUsage() (Usage, error) {
+	var u Usage
+	if fi, err := os.Stat(r.currentPath()); err == nil {
+		u.Current = fi.Size()
+	} else if !os.IsNotExist(err) {
+		return u, fmt.Errorf("rotate: stat current file: %w", err)
+	}
+	archives, err := r.archives()
+	if err != nil {
+		return u, fmt.Errorf("rotate: list archives: %w", err)
+	}
+	for _, a := range archives {
+		u.Archives += a.Size
+	}
+	u.ArchiveCount = len(archives)
+	if u.Free, _, err = r.archiveFree(); err != nil {
+		return u, fmt.Errorf("rotate: check free space: %w", err)
+	}
+	return u, nil
+}
+
+// LogicalDiskUsage is like DiskUsage but also computes
+// ArchivesLogical.  The size of a compressed archive is taken from
+// its sidecar metadata if it has one, otherwise the archive is read
+// through, which can take a while.
+func (r *Writer) LogicalDiskUsage() (Usage, error) {
+	u, err := r.DiskUsage()
+	if err != nil {
+		return u, err
+	}
+	archives, err := r.Archives()
+	if err != nil {
+		return u, err
+	}
+	for _, a := range archives {
+		n, err := logicalSize(a)
+		if err != nil {
+			return u, fmt.Errorf("rotate: read %s: %w", a.Name, err)
+		}
+		u.ArchivesLogical += n
+	}
+	return u, nil
+}
+
+// logicalSize returns the uncompressed size of an archive.
+func logicalSize(a ArchiveInfo) (int64, error) {
+	if a.Meta != nil {
+		return a.Meta.Bytes, nil
+	}
+	c := compressorFor(a.Name)
+	if c == nil {
+		return a.Size, nil
+	}
+	f, err := os.Open(a.Path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	rc, err := c.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	n, _, err := countLines(rc)
+	return n, err
+}
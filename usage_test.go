@@ -0,0 +1,70 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestDiskUsage(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	x.SetMax(10)
+	for i := 0; i < 5; i++ {
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	u, err := x.DiskUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Current != 6 || u.Archives != 24 || u.ArchiveCount != 2 || u.Total() != 30 {
+		t.Errorf("usage: %+v, expected 6 current and 24 bytes in 2 archives", u)
+	}
+	if u.Free == 0 {
+		t.Error("no free space reported")
+	}
+}
+
+func TestLogicalDiskUsage(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt", WithLiveCompression(Gzip))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	x.SetMax(10)
+	for i := 0; i < 4; i++ {
+		if i == 2 {
+			x.SetSidecar(true)
+		}
+		if _, err := x.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	u, err := x.LogicalDiskUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.ArchiveCount != 2 || u.ArchivesLogical != 24 {
+		t.Errorf("usage: %+v, expected 24 logical bytes in 2 archives", u)
+	}
+	if u.Archives == u.ArchivesLogical {
+		t.Errorf("compressed size %d equals the logical size", u.Archives)
+	}
+}
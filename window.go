@@ -0,0 +1,36 @@
+package rotate
+
+import "time"
+
+// SetQuietWindow holds back rotations by age, those of
+// IntervalTrigger, and the compression policy's work until the local
+// time of day is between start and end, given as durations since
+// midnight, so heavy I/O is done in quiet hours on constrained
+// appliances.  For 02:00 to 04:00:
+//
+//	w.SetQuietWindow(2*time.Hour, 4*time.Hour)
+//
+// The window may span midnight.  Rotations for any other reason, such
+// as a file reaching its maximum size, are not held back, so with
+// AnyTrigger the size limit is still enforced.  Equal start and end
+// turn the window off, which is the default.
+func (r *Writer) SetQuietWindow(start, end time.Duration) {
+	r.Lock()
+	r.windowStart, r.windowEnd = start, end
+	r.Unlock()
+}
+
+// inWindow reports whether t is in the quiet window, or there is
+// none.  The lock must be held.
+func (r *Writer) inWindow(t time.Time) bool {
+	start, end := r.windowStart, r.windowEnd
+	if start == end {
+		return true
+	}
+	y, m, d := t.Date()
+	off := t.Sub(time.Date(y, m, d, 0, 0, 0, 0, t.Location()))
+	if start < end {
+		return off >= start && off < end
+	}
+	return off >= start || off < end
+}
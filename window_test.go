@@ -0,0 +1,57 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestQuietWindow(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	x.SetSidecar(true)
+	x.SetTrigger(AnyTrigger(SizeTrigger(10), IntervalTrigger(time.Nanosecond)))
+
+	// a window an hour from now, so rotations by age wait
+	now := time.Now()
+	y, m, d := now.Date()
+	off := now.Sub(time.Date(y, m, d, 0, 0, 0, 0, now.Location()))
+	x.SetQuietWindow((off+time.Hour)%(24*time.Hour), (off+2*time.Hour)%(24*time.Hour))
+
+	write := func(s string) {
+		if _, err := x.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a\n")
+	write("b\n")
+	if c := x.GetCounter(); c != 1 {
+		t.Fatalf("rotated by age outside the window")
+	}
+	// the size limit still applies
+	write("hello world\n")
+	if c := x.GetCounter(); c != 2 {
+		t.Fatalf("not rotated by size outside the window")
+	}
+
+	// a window around now
+	x.SetQuietWindow((off+23*time.Hour)%(24*time.Hour), (off+time.Hour)%(24*time.Hour))
+	write("c\n")
+	archives, err := x.Archives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 2 || archives[0].Meta.Reason != ReasonSize || archives[1].Meta.Reason != ReasonTime {
+		t.Errorf("archives: %+v", archives)
+	}
+}
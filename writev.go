@@ -0,0 +1,111 @@
+package rotate
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// WriteVector writes the concatenation of bufs as one record, with a
+// single writev system call where the platform has one, so a caller
+// that builds a message from several segments, such as a header and
+// a payload, need not copy them into one slice.  A net.Buffers can
+// be passed as bufs.  The file is rotated after the record, as with
+// Write, so a record is never split across files.
+//
+// With interceptors the segments are joined, since an interceptor
+// sees each record whole.
+func (r *Writer) WriteVector(bufs [][]byte) (int64, error) {
+	if r.async != nil {
+		n, err := r.async.enqueue(bytes.Join(bufs, nil), false)
+		return int64(n), err
+	}
+	r.Lock()
+	defer r.Unlock()
+	if err := r.ready(); err != nil {
+		return 0, err
+	}
+	if r.intercepting() {
+		n, err := r.writeIntercepted(bytes.Join(bufs, nil))
+		return int64(n), err
+	}
+	return r.writeVector(bufs)
+}
+
+// writeVector writes bufs to the current file and rotates if
+// needed.  The lock must be held.
+func (r *Writer) writeVector(bufs [][]byte) (int64, error) {
+	if r.ring != nil {
+		n, err := r.write(bytes.Join(bufs, nil))
+		return int64(n), err
+	}
+	if i := r.markerIndex(bufs); i > 0 {
+		// rotate between the segments, at the marker
+		n, err := r.writeVector(bufs[:i])
+		if err != nil {
+			return n, err
+		}
+		m, err := r.writeVector(bufs[i:])
+		return n + m, err
+	}
+	if err := r.lockShared(); err != nil {
+		return 0, err
+	}
+	defer r.unlockShared()
+	r.switchBack()
+	if err := r.rollBucket(); err != nil {
+		return 0, err
+	}
+	if len(bufs) > 0 {
+		if err := r.rotateBefore(bufs[0]); err != nil {
+			return 0, err
+		}
+	}
+	r.checkSize()
+	n, err := r.writeBufs(bufs)
+	if err != nil && r.failover(err) {
+		var m int
+		m, err = r.out.Write(bytes.Join(bufs, nil)[n:])
+		n += int64(m)
+	}
+	r.size += int(n)
+	r.dirty = true
+	r.countWrite(int(n))
+	r.checkSoftLimit()
+	if err != nil {
+		return n, fmt.Errorf("rotate: write: %w", err)
+	}
+	rotate := false
+	var last []byte
+	for _, b := range bufs {
+		if r.written(b) {
+			rotate = true
+		}
+		if len(b) > 0 {
+			last = b
+		}
+	}
+	if err := r.flushLine(last); err != nil {
+		return n, err
+	}
+	if err := r.rotateAfter(last, rotate); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// writeBufs writes bufs to the current file.  The lock must be held.
+func (r *Writer) writeBufs(bufs [][]byte) (int64, error) {
+	if r.enc == nil && r.buf == nil {
+		return writev(r.current, bufs)
+	}
+	// the compressor or buffer copies them anyway
+	var n int64
+	for _, b := range bufs {
+		m, err := r.out.Write(b)
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,65 @@
+package rotate
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// maxIovecs is IOV_MAX, the most buffers one writev accepts.
+const maxIovecs = 1024
+
+// writev writes bufs to f with as few writev calls as the kernel
+// allows.
+func writev(f *os.File, bufs [][]byte) (int64, error) {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	// bufs is trimmed as it is written, so work on a copy
+	bufs = append([][]byte(nil), bufs...)
+	iov := make([]syscall.Iovec, 0, min(len(bufs), maxIovecs))
+	var n int64
+	for {
+		iov = iov[:0]
+		for _, b := range bufs {
+			if len(iov) == maxIovecs {
+				break
+			}
+			if len(b) == 0 {
+				continue
+			}
+			v := syscall.Iovec{Base: &b[0]}
+			v.SetLen(len(b))
+			iov = append(iov, v)
+		}
+		if len(iov) == 0 {
+			return n, nil
+		}
+
+		var m uintptr
+		var errno syscall.Errno
+		err := rc.Write(func(fd uintptr) bool {
+			m, _, errno = syscall.Syscall(syscall.SYS_WRITEV, fd, uintptr(unsafe.Pointer(&iov[0])), uintptr(len(iov)))
+			return errno != syscall.EAGAIN
+		})
+		if err != nil {
+			return n, err
+		}
+		if errno == syscall.EINTR {
+			continue
+		}
+		if errno != 0 {
+			return n, os.NewSyscallError("writev", errno)
+		}
+		n += int64(m)
+		for m > 0 {
+			if uintptr(len(bufs[0])) > m {
+				bufs[0] = bufs[0][m:]
+				break
+			}
+			m -= uintptr(len(bufs[0]))
+			bufs = bufs[1:]
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package rotate
+
+import "os"
+
+// writev writes bufs to f one at a time, where there is no writev.
+func writev(f *os.File, bufs [][]byte) (int64, error) {
+	var n int64
+	for _, b := range bufs {
+		m, err := f.Write(b)
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
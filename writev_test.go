@@ -0,0 +1,63 @@
+package rotate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestWriteVector(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	x, err := New(root, "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(10)
+	n, err := x.WriteVector(net.Buffers{[]byte("2024 "), nil, []byte("hello"), []byte("\n")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 11 {
+		t.Errorf("wrote %d bytes, expected 11", n)
+	}
+	if _, err := x.WriteVector([][]byte{[]byte("a"), []byte("b")}); err != nil {
+		t.Fatal(err)
+	}
+	x.Use(bytes.ToUpper)
+	if _, err := x.WriteVector([][]byte{[]byte("c"), []byte("d\n")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if b, _ := ioutil.ReadFile(path.Join(root, "mt_1")); string(b) != "2024 hello\n" {
+		t.Errorf("mt_1: %q, expected a whole record", b)
+	}
+	if b, _ := ioutil.ReadFile(path.Join(root, "default.log")); string(b) != "abCD\n" {
+		t.Errorf("current: %q, expected %q", b, "abCD\n")
+	}
+
+	// many buffers need several writev calls
+	many := make([][]byte, 3000)
+	for i := range many {
+		many[i] = []byte("x")
+	}
+	x, err = New(path.Join(root, "many"), "mt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMax(1 << 20)
+	if n, err := x.WriteVector(many); err != nil || n != 3000 {
+		t.Errorf("WriteVector of 3000 buffers: %d, %v", n, err)
+	}
+	x.Close()
+}
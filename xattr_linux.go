@@ -0,0 +1,42 @@
+package rotate
+
+import (
+	"bytes"
+	"syscall"
+)
+
+// copyXattrs copies the extended attributes of src, including its
+// SELinux context, to dst, so confined readers can still read a
+// copy.  It is best effort: the destination filesystem may not
+// support them, and setting security labels may take privileges
+// the process lacks.  It returns the first error but copies what it
+// can.
+func copyXattrs(src, dst string) error {
+	size, err := syscall.Listxattr(src, nil)
+	if err != nil || size == 0 {
+		return err
+	}
+	list := make([]byte, size)
+	if size, err = syscall.Listxattr(src, list); err != nil {
+		return err
+	}
+	var first error
+	for _, name := range bytes.Split(bytes.TrimRight(list[:size], "\x00"), []byte{0}) {
+		attr := string(name)
+		n, err := syscall.Getxattr(src, attr, nil)
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		val := make([]byte, n)
+		if n, err = syscall.Getxattr(src, attr, val); err == nil {
+			err = syscall.Setxattr(dst, attr, val[:n], 0)
+		}
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
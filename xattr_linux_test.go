@@ -0,0 +1,36 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"syscall"
+	"testing"
+)
+
+func TestCopyXattrs(t *testing.T) {
+	root, err := ioutil.TempDir("", "multitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	src, dst := path.Join(root, "src"), path.Join(root, "dst")
+	if err := ioutil.WriteFile(src, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Setxattr(src, "user.rotate", []byte("test"), 0); err != nil {
+		t.Skipf("extended attributes not supported: %v", err)
+	}
+	if err := copyFile(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	val := make([]byte, 16)
+	n, err := syscall.Getxattr(dst, "user.rotate", val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(val[:n]) != "test" {
+		t.Errorf("attribute: %q, expected %q", val[:n], "test")
+	}
+}
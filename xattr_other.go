@@ -0,0 +1,9 @@
+//go:build !linux
+
+package rotate
+
+// copyXattrs does nothing where extended attributes are not
+// supported by the syscall package.
+func copyXattrs(src, dst string) error {
+	return nil
+}